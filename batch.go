@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go-reloaded/internal/io"
+)
+
+// batchOptions controls where runBatch writes its results.
+type batchOptions struct {
+	outDir string // mirror inputs into this directory
+	ext    string // extension picked up when walking directories
+	jobs   int    // concurrent workers
+
+	overwrite io.OverwritePolicy // what to do with outputs that already exist
+
+	inPlace      bool   // replace each input instead of writing to outDir
+	backupSuffix string // with inPlace: keep the original as <file><suffix>
+
+	pipelines *pipelineCache
+}
+
+// batchResult is the outcome of processing one input file in batch mode.
+type batchResult struct {
+	in, out string
+	skipped bool
+	err     error
+}
+
+// runBatch processes every file named by args (files, directories, globs),
+// either into opts.outDir (mirroring the input tree) or in place, using up to
+// opts.jobs concurrent workers. It prints one summary line per file and returns
+// the exit code: exitError if any file failed, exitCancelled if any output was
+// left alone, exitOK otherwise.
+func runBatch(args []string, opts batchOptions) int {
+	inputs, err := io.ExpandInputs(args, opts.ext)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	outs := make([]string, len(inputs))
+	if opts.inPlace {
+		for i, in := range inputs {
+			outs[i] = in.Path
+		}
+	} else if outs, err = io.OutputPaths(inputs, opts.outDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	// decide about existing outputs up front, so prompts are not interleaved
+	results := make([]batchResult, len(inputs))
+	for i, in := range inputs {
+		results[i] = batchResult{in: in.Path, out: outs[i]}
+		if opts.inPlace {
+			continue
+		}
+		if err := io.CheckOverwrite(outs[i], opts.overwrite); err != nil {
+			results[i].skipped = errors.Is(err, io.ErrCancelled)
+			if !results[i].skipped {
+				results[i].err = err
+			}
+		}
+	}
+
+	jobs := opts.jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				r := &results[i]
+				r.err = processFile(opts.pipelines, r.in, r.out, opts.inPlace, opts.backupSuffix)
+			}
+		}()
+	}
+	for i, r := range results {
+		if !r.skipped && r.err == nil {
+			work <- i
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	failed, skipped := 0, 0
+	for _, r := range results {
+		switch {
+		case r.err != nil:
+			failed++
+			fmt.Printf("FAIL %s: %v\n", r.in, r.err)
+		case r.skipped:
+			skipped++
+			fmt.Printf("skip %s: %s exists\n", r.in, r.out)
+		default:
+			fmt.Printf("ok   %s -> %s\n", r.in, r.out)
+		}
+	}
+	fmt.Printf("%d file(s), %d failed, %d skipped\n", len(results), failed, skipped)
+	switch {
+	case failed > 0:
+		return exitError
+	case skipped > 0:
+		return exitCancelled
+	}
+	return exitOK
+}
+
+// processFile runs the pipeline over one file and writes the result, creating
+// parent directories as needed. In place, the input is replaced atomically.
+func processFile(pipelines *pipelineCache, in, out string, inPlace bool, backupSuffix string) error {
+	text, err := io.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	result, err := pipelines.process(in, text)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	if inPlace {
+		return io.ReplaceFile(out, result, backupSuffix)
+	}
+	return io.WriteFile(out, result)
+}
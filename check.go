@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"go-reloaded/internal/diff"
+	"go-reloaded/internal/io"
+)
+
+// runCheck implements --check and --diff: nothing is written. With list it prints
+// the name of every input that would change; with showDiff it prints a unified
+// diff for it. No args means stdin. Returns exitDirty if anything would change.
+func runCheck(pipelines *pipelineCache, args []string, ext string, list, showDiff bool) int {
+	inputs := []io.Input{{Path: io.Stdio, Rel: "<stdin>"}}
+	if len(args) > 0 {
+		var err error
+		if inputs, err = io.ExpandInputs(args, ext); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	}
+
+	code := exitOK
+	for _, in := range inputs {
+		name := in.Path
+		if name == io.Stdio {
+			name = in.Rel
+		}
+
+		text, err := io.ReadFile(in.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			code = exitError
+			continue
+		}
+		result, err := pipelines.process(in.Path, text)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			code = exitError
+			continue
+		}
+		if result == text {
+			continue
+		}
+
+		if code == exitOK {
+			code = exitDirty
+		}
+		if list {
+			fmt.Println(name)
+		}
+		if showDiff {
+			fmt.Print(diff.Unified(name+".orig", name, text, result))
+		}
+	}
+	return code
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"go-reloaded/internal/explain"
+	"go-reloaded/internal/io"
+)
+
+// runExplain implements --explain[=json]: nothing is written; for every input
+// (stdin if none) it prints which stage inserted, removed or modified which token.
+func runExplain(pipelines *pipelineCache, args []string, ext, format string) int {
+	if format != "" && format != "text" && format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown --explain format %q (want text or json)\n", format)
+		return exitError
+	}
+	inputs := []io.Input{{Path: io.Stdio, Rel: "<stdin>"}}
+	if len(args) > 0 {
+		var err error
+		if inputs, err = io.ExpandInputs(args, ext); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	}
+
+	code := exitOK
+	var reports []explain.Report
+	for _, in := range inputs {
+		name := in.Path
+		if name == io.Stdio {
+			name = in.Rel
+		}
+		text, err := io.ReadFile(in.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			code = exitError
+			continue
+		}
+		p, err := pipelines.forInput(in.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			code = exitError
+			continue
+		}
+		_, report := explain.Run(p, name, text)
+		if format == "json" {
+			reports = append(reports, report)
+			continue
+		}
+		if err := explain.WriteText(os.Stdout, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	}
+	if format == "json" {
+		if err := explain.WriteJSON(os.Stdout, reports); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	}
+	return code
+}
@@ -0,0 +1,30 @@
+package main
+
+// optionalFlag is a boolean flag that can optionally carry a value:
+// --name sets it, --name=VALUE sets it with VALUE (e.g. --in-place=.bak, like sed -i.bak).
+type optionalFlag struct {
+	enabled bool
+	value   string
+}
+
+func (f *optionalFlag) String() string {
+	if f == nil || !f.enabled {
+		return ""
+	}
+	return f.value
+}
+
+func (f *optionalFlag) Set(v string) error {
+	switch v {
+	case "true":
+		f.enabled, f.value = true, ""
+	case "false":
+		f.enabled, f.value = false, ""
+	default:
+		f.enabled, f.value = true, v
+	}
+	return nil
+}
+
+// IsBoolFlag lets the flag package accept the bare --name form.
+func (f *optionalFlag) IsBoolFlag() bool { return true }
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-reloaded/internal/pipeline"
+	"go-reloaded/internal/transform"
+)
+
+// FileNames are the config files Find looks for, in order of preference.
+var FileNames = []string{".goreloaded.toml", ".goreloaded.json"}
+
+// Config is the content of a .goreloaded.toml / .goreloaded.json file.
+//
+//	stages  = ["validate-tags", "hex", ...]  # optional: replaces the default order
+//	disable = ["articles"]                  # stage or rule names to switch off
+//
+//	[articles]
+//	an = ["hon"]       # extra prefixes that take "an" (silent h)
+//	a  = ["usu"]       # extra prefixes that take "a" ('y' sound)
+//
+//	[case]
+//	max_range = 10     # (up, n) with n > 10 is treated as malformed
+//
+//	[punctuation]
+//	marks = [".", ",", "!", "?"]  # replaces the default set of spaced marks
+type Config struct {
+	Stages  []string `json:"stages"`
+	Disable []string `json:"disable"`
+
+	Articles struct {
+		An []string `json:"an"`
+		A  []string `json:"a"`
+	} `json:"articles"`
+
+	Case struct {
+		MaxRange int `json:"max_range"`
+	} `json:"case"`
+
+	Punctuation struct {
+		Marks []string `json:"marks"`
+	} `json:"punctuation"`
+
+	// Path is the file the config was loaded from ("" for the built-in default).
+	Path string `json:"-"`
+}
+
+// Load reads a config file; the format is picked by extension (.toml or .json).
+// Unknown keys are errors, so typos do not go unnoticed.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		m, err := parseTOML(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		// re-encode so both formats share the JSON field mapping and checks
+		if data, err = json.Marshal(m); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	var c Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	c.Path = path
+	return &c, nil
+}
+
+// Find walks up from dir to the filesystem root and returns the first config
+// file found, or "" if there is none.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// ForDir returns the config file that applies to files in dir: explicit (the
+// --config flag) if it is not empty, otherwise the file Find discovers from
+// dir, or "" if there is none.
+func ForDir(explicit, dir string) (string, error) {
+	if explicit != "" {
+		return explicit, nil
+	}
+	return Find(dir)
+}
+
+// Options converts c into pipeline options.
+func (c *Config) Options() pipeline.Options {
+	opts := pipeline.Options{
+		Stages:  c.Stages,
+		Disable: c.Disable,
+		Case:    transform.CaseRules{MaxRange: c.Case.MaxRange},
+	}
+	if len(c.Articles.An) > 0 || len(c.Articles.A) > 0 {
+		d := transform.DefaultArticleRules
+		opts.Articles = &transform.ArticleRules{
+			An: append(append([]string(nil), d.An...), c.Articles.An...),
+			A:  append(append([]string(nil), d.A...), c.Articles.A...),
+		}
+	}
+	if c.Punctuation.Marks != nil {
+		opts.Punctuation = &transform.PunctRules{Marks: c.Punctuation.Marks}
+	}
+	return opts
+}
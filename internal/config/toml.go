@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML understands the subset of TOML that config files need:
+// comments, [table] and [table.sub] headers, and key = value pairs where a
+// value is a string ("basic" or 'literal'), integer, boolean, or an array of
+// those (arrays may span lines).
+func parseTOML(src string) (map[string]any, error) {
+	root := map[string]any{}
+	table := root
+
+	lines := strings.Split(src, "\n")
+	for n := 0; n < len(lines); n++ {
+		lineNo := n + 1
+		line := strings.TrimSpace(stripComment(lines[n]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: unsupported table header %q", lineNo, line)
+			}
+			table = root
+			for _, part := range strings.Split(line[1:len(line)-1], ".") {
+				key, err := parseKey(part)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNo, err)
+				}
+				next, ok := table[key].(map[string]any)
+				if !ok {
+					if _, taken := table[key]; taken {
+						return nil, fmt.Errorf("line %d: %q is not a table", lineNo, key)
+					}
+					next = map[string]any{}
+					table[key] = next
+				}
+				table = next
+			}
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key, err := parseKey(line[:eq])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		raw := strings.TrimSpace(line[eq+1:])
+		// multi-line arrays: keep reading until the brackets balance
+		for strings.HasPrefix(raw, "[") && !bracketsBalanced(raw) && n+1 < len(lines) {
+			n++
+			raw += " " + strings.TrimSpace(stripComment(lines[n]))
+		}
+		val, rest, err := parseValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("line %d: unexpected %q after value", lineNo, rest)
+		}
+		if _, dup := table[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNo, key)
+		}
+		table[key] = val
+	}
+	return root, nil
+}
+
+func parseKey(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", fmt.Errorf("empty key")
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		v, rest, err := parseString(s)
+		if err != nil || strings.TrimSpace(rest) != "" {
+			return "", fmt.Errorf("bad key %q", s)
+		}
+		return v, nil
+	}
+	for _, r := range s {
+		if !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return "", fmt.Errorf("bad key %q", s)
+		}
+	}
+	return s, nil
+}
+
+// parseValue parses one value at the start of s and returns the remainder.
+func parseValue(s string) (any, string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return nil, "", fmt.Errorf("missing value")
+	case s[0] == '"' || s[0] == '\'':
+		return parseString(s)
+	case s[0] == '[':
+		var arr []any
+		s = strings.TrimSpace(s[1:])
+		for {
+			if strings.HasPrefix(s, "]") {
+				return arr, s[1:], nil
+			}
+			v, rest, err := parseValue(s)
+			if err != nil {
+				return nil, "", err
+			}
+			arr = append(arr, v)
+			s = strings.TrimSpace(rest)
+			if strings.HasPrefix(s, ",") {
+				s = strings.TrimSpace(s[1:])
+			} else if !strings.HasPrefix(s, "]") {
+				return nil, "", fmt.Errorf("expected , or ] in array")
+			}
+		}
+	}
+
+	end := strings.IndexAny(s, ",] \t")
+	if end < 0 {
+		end = len(s)
+	}
+	word, rest := s[:end], s[end:]
+	switch word {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(word, "_", ""), 0, 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("unsupported value %q", word)
+	}
+	return n, rest, nil
+}
+
+func parseString(s string) (string, string, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			if quote == '\'' {
+				return s[1:i], s[i+1:], nil
+			}
+			v, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", fmt.Errorf("bad string %s", s[:i+1])
+			}
+			return v, s[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string")
+}
+
+// stripComment drops a trailing # comment that is not inside a string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func bracketsBalanced(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && c == '[':
+			depth++
+		case quote == 0 && c == ']':
+			depth--
+		}
+	}
+	return depth <= 0
+}
@@ -0,0 +1,132 @@
+package diag
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+)
+
+// Tool identifies the analyzer in a SARIF log.
+type Tool struct {
+	Name    string
+	Version string
+	URI     string
+}
+
+// SARIF 2.1.0 log, reduced to the parts we fill in.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine   int `json:"startLine"`
+			StartColumn int `json:"startColumn"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+// WriteSARIF writes ds as a SARIF 2.1.0 log with one run for tool.
+// Every rule is listed (in order) so rule indexes stay stable; all results
+// are warnings. Columns are counted in Unicode code points, like Diagnostic.Col.
+func WriteSARIF(w io.Writer, tool Tool, rules []Rule, ds []Diagnostic) error {
+	driver := sarifDriver{Name: tool.Name, Version: tool.Version, InformationURI: tool.URI, Rules: []sarifRule{}}
+	index := map[string]int{}
+	for i, r := range rules {
+		sr := sarifRule{ID: r.ID, ShortDescription: sarifMessage{r.Description}}
+		sr.DefaultConfiguration.Level = "warning"
+		driver.Rules = append(driver.Rules, sr)
+		index[r.ID] = i
+	}
+
+	results := []sarifResult{}
+	for _, d := range ds {
+		i, ok := index[d.Rule]
+		if !ok {
+			// keep unknown rule IDs valid by adding them on the fly
+			i = len(driver.Rules)
+			sr := sarifRule{ID: d.Rule, ShortDescription: sarifMessage{d.Rule}}
+			sr.DefaultConfiguration.Level = "warning"
+			driver.Rules = append(driver.Rules, sr)
+			index[d.Rule] = i
+		}
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = fileURI(d.File)
+		loc.PhysicalLocation.Region.StartLine = d.Line
+		loc.PhysicalLocation.Region.StartColumn = d.Col
+		results = append(results, sarifResult{
+			RuleID:    d.Rule,
+			RuleIndex: i,
+			Level:     "warning",
+			Message:   sarifMessage{d.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:       sarifTool{Driver: driver},
+			ColumnKind: "unicodeCodePoints",
+			Results:    results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// fileURI turns a file name into a URI reference: absolute paths become
+// file:// URIs, relative ones stay relative (percent-encoded).
+func fileURI(name string) string {
+	u := url.URL{Path: filepath.ToSlash(name)}
+	if filepath.IsAbs(name) {
+		u.Scheme = "file"
+	}
+	return u.String()
+}
@@ -0,0 +1,258 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	line string // includes its trailing "\n" unless it is the last line without one
+}
+
+// Unified returns a unified diff (as printed by `diff -u`) turning a into b,
+// labelled with aName and bName. It returns "" when a == b.
+func Unified(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := lineOps(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+
+	// walk ops, emitting a hunk for each run of changes plus context
+	i := 0
+	aLine, bLine := 1, 1 // 1-based line numbers at ops[i]
+	for i < len(ops) {
+		if ops[i].kind == opEqual {
+			i++
+			aLine++
+			bLine++
+			continue
+		}
+		// hunk start: back up over leading context
+		start := i
+		for start > 0 && i-start < context && ops[start-1].kind == opEqual {
+			start--
+		}
+		aStart, bStart := aLine-(i-start), bLine-(i-start)
+
+		// hunk end: extend while changes are within 2*context of each other
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end += min(context, run-end)
+				break
+			}
+			end = run
+		}
+
+		aCount, bCount := 0, 0
+		for _, o := range ops[start:end] {
+			if o.kind != opInsert {
+				aCount++
+			}
+			if o.kind != opDelete {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, o := range ops[start:end] {
+			sb.WriteByte(byte(o.kind))
+			sb.WriteString(o.line)
+			if !strings.HasSuffix(o.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		// advance line counters over [i, end)
+		for _, o := range ops[i:end] {
+			if o.kind != opInsert {
+				aLine++
+			}
+			if o.kind != opDelete {
+				bLine++
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// empty range points at the line before
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Edit is one step of an edit script turning a into b. Op is OpEqual,
+// OpDelete or OpInsert. A and B are the positions in a and b at that step:
+// a[A] is the element kept or deleted, b[B] the element kept or inserted
+// (an insert goes before a[A]).
+type Edit struct {
+	Op   byte
+	A, B int
+}
+
+// Edit operations.
+const (
+	OpEqual  = byte(opEqual)
+	OpDelete = byte(opDelete)
+	OpInsert = byte(opInsert)
+)
+
+// Strings computes a shortest edit script from a to b with Myers' O(ND)
+// algorithm in its linear-space form: each step finds the middle snake of the
+// remaining region and splits the problem there, so memory stays O(N+M) however
+// many edits there are.
+func Strings(a, b []string) []Edit {
+	d := differ{a: a, b: b}
+	d.compare(0, len(a), 0, len(b))
+	return d.edits
+}
+
+func lineOps(a, b []string) []op {
+	edits := Strings(a, b)
+	ops := make([]op, len(edits))
+	for i, e := range edits {
+		if e.Op == OpInsert {
+			ops[i] = op{opInsert, b[e.B]}
+		} else {
+			ops[i] = op{opKind(e.Op), a[e.A]}
+		}
+	}
+	return ops
+}
+
+type differ struct {
+	a, b   []string
+	edits  []Edit
+	vf, vb []int // furthest reaching x per diagonal, forward and backward
+}
+
+// compare appends the edits turning a[aLo:aHi] into b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	// common prefix and suffix
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.edits = append(d.edits, Edit{OpEqual, aLo, bLo})
+		aLo++
+		bLo++
+	}
+	suf := 0
+	for aLo < aHi-suf && bLo < bHi-suf && d.a[aHi-1-suf] == d.b[bHi-1-suf] {
+		suf++
+	}
+	aHi, bHi = aHi-suf, bHi-suf
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			d.edits = append(d.edits, Edit{OpInsert, aLo, y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			d.edits = append(d.edits, Edit{OpDelete, x, bLo})
+		}
+	default:
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.edits = append(d.edits, Edit{OpEqual, x, y})
+		}
+		d.compare(u, aHi, v, bHi)
+	}
+
+	for i := 0; i < suf; i++ {
+		d.edits = append(d.edits, Edit{OpEqual, aHi + i, bHi + i})
+	}
+}
+
+// middleSnake returns the snake (x, y) -> (u, v) in the middle of a shortest
+// edit path from (aLo, bLo) to (aHi, bHi), running the search forward from the
+// start and backward from the end until the two meet. Both regions must be
+// non-empty and must not share a prefix or suffix.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	maxD := (n + m + 1) / 2
+	off := maxD + 1
+	if size := 2*maxD + 3; len(d.vf) < size {
+		d.vf, d.vb = make([]int, size), make([]int, size)
+	}
+	vf, vb := d.vf, d.vb
+	// x positions are relative to aLo forward and to aHi backward
+	vf[off+1], vb[off+1] = 0, 0
+
+	for step := 0; step <= maxD; step++ {
+		for k := -step; k <= step; k += 2 {
+			var x0 int
+			if k == -step || (k != step && vf[off+k-1] < vf[off+k+1]) {
+				x0 = vf[off+k+1] // down: insertion
+			} else {
+				x0 = vf[off+k-1] + 1 // right: deletion
+			}
+			x1 := x0
+			for x1 < n && x1-k < m && d.a[aLo+x1] == d.b[bLo+x1-k] {
+				x1++
+			}
+			vf[off+k] = x1
+			// with an odd delta the paths can only meet on a forward step
+			if r := delta - k; delta%2 != 0 && r >= -(step-1) && r <= step-1 && x1+vb[off+r] >= n {
+				return aLo + x0, bLo + x0 - k, aLo + x1, bLo + x1 - k
+			}
+		}
+		for r := -step; r <= step; r += 2 {
+			var x0 int
+			if r == -step || (r != step && vb[off+r-1] < vb[off+r+1]) {
+				x0 = vb[off+r+1]
+			} else {
+				x0 = vb[off+r-1] + 1
+			}
+			x1 := x0
+			for x1 < n && x1-r < m && d.a[aHi-1-x1] == d.b[bHi-1-x1+r] {
+				x1++
+			}
+			vb[off+r] = x1
+			if k := delta - r; delta%2 == 0 && k >= -step && k <= step && x1+vf[off+k] >= n {
+				return aHi - x1, bHi - x1 + r, aHi - x0, bHi - x0 + r
+			}
+		}
+	}
+	panic("diff: no middle snake")
+}
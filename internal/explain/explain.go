@@ -0,0 +1,189 @@
+package explain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/diff"
+	"go-reloaded/internal/pipeline"
+	"go-reloaded/internal/token"
+)
+
+// Change is one token a stage inserted, removed or modified.
+//
+// Line and Col (1-based, columns in runes) locate the token in the original
+// input; for an insert they point at the token it was inserted before.
+// Synthetic marks a removed/modified token that an earlier stage made up; its
+// position is that of the nearest input token.
+type Change struct {
+	Stage     string `json:"stage"`
+	Op        string `json:"op"` // "insert", "remove" or "modify"
+	Line      int    `json:"line"`
+	Col       int    `json:"col"`
+	Kind      string `json:"kind"`
+	Before    string `json:"before,omitempty"`
+	After     string `json:"after,omitempty"`
+	Synthetic bool   `json:"synthetic,omitempty"`
+}
+
+// Report is the explanation for one input.
+// Notes explain tags that did not change anything, such as a (hex) whose
+// word is not a hexadecimal number; stages the pipeline does not run have none.
+type Report struct {
+	File    string            `json:"file"`
+	Changes []Change          `json:"changes"`
+	Notes   []diag.Diagnostic `json:"notes,omitempty"`
+}
+
+// Run processes text with p and records what every stage changed.
+// It returns the output (identical to p.Process) and the report.
+func Run(p *pipeline.Pipeline, file, text string) (string, Report) {
+	r := Report{File: file, Changes: []Change{}}
+	out := p.ProcessTrace(text, func(stage string, before, after []token.Tok) {
+		r.Changes = append(r.Changes, stageChanges(stage, before, after)...)
+	})
+	r.Notes = p.LintNumbers(text)
+	for i := range r.Notes {
+		r.Notes[i].File = file
+	}
+	return out, r
+}
+
+// locate returns the input position for toks[i]: its own if it came from the
+// input, otherwise that of the nearest following (then preceding) input token.
+// i == len(toks) means the end of the stream.
+func locate(toks []token.Tok, i int) (line, col int, synthetic bool) {
+	synthetic = i >= len(toks) || toks[i].Synthetic()
+	for j := i; j < len(toks); j++ {
+		if !toks[j].Synthetic() {
+			return toks[j].Line, toks[j].Col, synthetic
+		}
+	}
+	for j := min(i, len(toks)) - 1; j >= 0; j-- {
+		if !toks[j].Synthetic() {
+			return toks[j].Line, toks[j].Col, synthetic
+		}
+	}
+	return 1, 1, synthetic
+}
+
+func stageChanges(stage string, before, after []token.Tok) []Change {
+	key := func(toks []token.Tok) []string {
+		keys := make([]string, len(toks))
+		for i, t := range toks {
+			keys[i] = t.K.String() + "\x00" + t.Text
+		}
+		return keys
+	}
+	edits := lineEdits(key(before), key(after))
+
+	var changes []Change
+	for i := 0; i < len(edits); i++ {
+		e := edits[i]
+		line, col, synthetic := locate(before, e.A)
+		c := Change{Stage: stage, Line: line, Col: col}
+		switch e.Op {
+		case diff.OpEqual:
+			continue
+		case diff.OpDelete:
+			b := before[e.A]
+			c.Kind, c.Before, c.Synthetic = b.K.String(), b.Text, synthetic
+			c.Op = "remove"
+			// a removal directly followed by an insert of the same kind is a modification
+			if i+1 < len(edits) && edits[i+1].Op == diff.OpInsert && after[edits[i+1].B].K == b.K {
+				c.Op, c.After = "modify", after[edits[i+1].B].Text
+				i++
+			}
+		case diff.OpInsert:
+			a := after[e.B]
+			c.Op, c.Kind, c.After = "insert", a.K.String(), a.Text
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// lineEdits diffs a and b line by line when both have the same line breaks,
+// which is the common case, and as a whole otherwise. Keys containing "\n" are
+// the line breaks.
+func lineEdits(a, b []string) []diff.Edit {
+	breaks := func(keys []string) []int {
+		var idx []int
+		for i, k := range keys {
+			if strings.Contains(k, "\n") {
+				idx = append(idx, i)
+			}
+		}
+		return idx
+	}
+	ba, bb := breaks(a), breaks(b)
+	if len(ba) != len(bb) {
+		return diff.Strings(a, b)
+	}
+	for i := range ba {
+		if a[ba[i]] != b[bb[i]] {
+			return diff.Strings(a, b)
+		}
+	}
+
+	var edits []diff.Edit
+	pa, pb := 0, 0
+	for i := 0; i <= len(ba); i++ {
+		ea, eb := len(a), len(b)
+		if i < len(ba) {
+			ea, eb = ba[i], bb[i]
+		}
+		for _, e := range diff.Strings(a[pa:ea], b[pb:eb]) {
+			e.A += pa
+			e.B += pb
+			edits = append(edits, e)
+		}
+		if i < len(ba) {
+			edits = append(edits, diff.Edit{Op: diff.OpEqual, A: ea, B: eb})
+		}
+		pa, pb = ea+1, eb+1
+	}
+	return edits
+}
+
+// WriteText prints r as a readable report grouped by stage.
+func WriteText(w io.Writer, r Report) error {
+	var sb strings.Builder
+	if len(r.Changes) == 0 && len(r.Notes) == 0 {
+		fmt.Fprintf(&sb, "%s: no changes\n", r.File)
+	}
+	for i, c := range r.Changes {
+		if i == 0 || r.Changes[i-1].Stage != c.Stage {
+			fmt.Fprintf(&sb, "%s: stage %s\n", r.File, c.Stage)
+		}
+		fmt.Fprintf(&sb, "  %d:%d\t%-6s %-5s ", c.Line, c.Col, c.Op, c.Kind)
+		switch c.Op {
+		case "insert":
+			fmt.Fprintf(&sb, "%q", c.After)
+		case "remove":
+			fmt.Fprintf(&sb, "%q", c.Before)
+		default:
+			fmt.Fprintf(&sb, "%q -> %q", c.Before, c.After)
+		}
+		if c.Synthetic {
+			sb.WriteString("  (inserted by an earlier stage)")
+		}
+		sb.WriteByte('\n')
+	}
+	for _, n := range r.Notes {
+		fmt.Fprintf(&sb, "%s:%d:%d: note: %s: %s\n", n.File, n.Line, n.Col, n.Rule, n.Message)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteJSON prints reports as an indented JSON array.
+func WriteJSON(w io.Writer, reports []Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(reports)
+}
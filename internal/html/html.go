@@ -0,0 +1,189 @@
+// Package html runs the pipeline over the text nodes of an HTML or XML
+// document only.
+//
+// Tags (with their attributes), comments, CDATA sections, doctypes,
+// processing instructions, entities and the whole content of raw elements
+// (pre, code, script, style, textarea, kbd, samp) are cut out with a
+// markup.Doc and restored byte-for-byte afterwards. Inline elements such as
+// <em> or <a> do not separate the text around them, so "'<em>quoted</em>'"
+// is still one quoted span and "<b>word</b> (up)" still reaches the word.
+package html
+
+import (
+	"strings"
+
+	"go-reloaded/internal/markup"
+)
+
+// Process runs process over the text nodes of the HTML or XML document text.
+func Process(text string, process func(string) string) (string, error) {
+	if err := markup.CheckInput(text); err != nil {
+		return "", err
+	}
+	return Split(text).Process(process)
+}
+
+// rawElements keep their content as written.
+var rawElements = map[string]bool{
+	"pre": true, "code": true, "script": true, "style": true,
+	"textarea": true, "kbd": true, "samp": true,
+}
+
+// inlineElements do not break the flow of text.
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "cite": true,
+	"data": true, "del": true, "dfn": true, "em": true, "font": true, "i": true,
+	"ins": true, "label": true, "mark": true, "q": true, "s": true, "small": true,
+	"span": true, "strong": true, "sub": true, "sup": true, "time": true,
+	"u": true, "var": true,
+}
+
+// Split separates the text nodes of an HTML or XML document from its markup.
+func Split(text string) *markup.Doc {
+	d := &markup.Doc{}
+	s := text
+	for len(s) > 0 {
+		i := strings.IndexAny(s, "<&")
+		if i < 0 {
+			d.Text(s)
+			break
+		}
+		d.Text(s[:i])
+		s = s[i:]
+
+		if s[0] == '&' {
+			n := entityLen(s)
+			if n == 0 {
+				d.Text("&")
+				s = s[1:]
+				continue
+			}
+			d.Opaque(s[:n])
+			s = s[n:]
+			continue
+		}
+
+		n := tagLen(s)
+		if n == 0 {
+			// a lone '<' is text ("a < b")
+			d.Text("<")
+			s = s[1:]
+			continue
+		}
+		tag := s[:n]
+		name, closing := tagName(tag)
+		switch {
+		case !closing && rawElements[name] && !strings.HasSuffix(tag, "/>"):
+			n += rawContentLen(s[n:], name)
+			d.Opaque(s[:n])
+		case inlineElements[name]:
+			d.Markup(tag)
+		default:
+			d.Opaque(tag)
+		}
+		s = s[n:]
+	}
+	return d
+}
+
+// tagLen returns the length of the tag, comment, CDATA section, doctype or
+// processing instruction at the start of s, or 0 if s does not start one.
+func tagLen(s string) int {
+	for _, delim := range [][2]string{{"<!--", "-->"}, {"<![CDATA[", "]]>"}, {"<?", "?>"}} {
+		if strings.HasPrefix(s, delim[0]) {
+			if end := strings.Index(s[len(delim[0]):], delim[1]); end >= 0 {
+				return len(delim[0]) + end + len(delim[1])
+			}
+			return 0
+		}
+	}
+	if len(s) < 2 || !(isNameStart(s[1]) || s[1] == '/' || s[1] == '!') {
+		return 0
+	}
+	// scan to the closing '>', skipping quoted attribute values
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '<':
+			return 0
+		case c == '>':
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// tagName returns the lower-cased element name of a tag and whether it is a
+// closing tag; comments and the like have no name.
+func tagName(tag string) (name string, closing bool) {
+	s := strings.TrimPrefix(tag, "<")
+	if strings.HasPrefix(s, "/") {
+		closing, s = true, s[1:]
+	}
+	end := 0
+	for end < len(s) && (isNameStart(s[end]) || s[end] >= '0' && s[end] <= '9' || s[end] == '-' || s[end] == ':' || s[end] == '.' || s[end] == '_') {
+		end++
+	}
+	return strings.ToLower(s[:end]), closing
+}
+
+// rawContentLen returns the length of s up to and including the tag that
+// closes the raw element name (nested elements of the same name included), or
+// len(s) if it is never closed.
+func rawContentLen(s, name string) int {
+	depth := 1
+	for i := 0; i < len(s); {
+		j := strings.IndexByte(s[i:], '<')
+		if j < 0 {
+			break
+		}
+		i += j
+		n := tagLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		tag := s[i : i+n]
+		i += n
+		if tn, closing := tagName(tag); tn != name {
+			continue
+		} else if closing {
+			depth--
+		} else if name != "script" && name != "style" && !strings.HasSuffix(tag, "/>") {
+			// script and style hold plain text, so only their closing tag counts
+			depth++
+		}
+		if depth == 0 {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// entityLen returns the length of the character reference at the start of s
+// ("&amp;", "&#8212;", "&#x2014;"), or 0.
+func entityLen(s string) int {
+	i := 1
+	if i < len(s) && s[i] == '#' {
+		i++
+		if i < len(s) && (s[i] == 'x' || s[i] == 'X') {
+			i++
+		}
+	}
+	start := i
+	for i < len(s) && i-start < 32 && (isNameStart(s[i]) || s[i] >= '0' && s[i] <= '9') {
+		i++
+	}
+	if i == start || i >= len(s) || s[i] != ';' {
+		return 0
+	}
+	return i + 1
+}
+
+func isNameStart(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' }
@@ -0,0 +1,88 @@
+package io
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ReplaceFile atomically replaces filename with content.
+//
+// The data is written to a temporary file in the same directory, synced to disk
+// and renamed over the target, so readers see either the old or the new file and
+// never a half-written one. An existing file keeps its permission bits.
+// If backupSuffix is not empty, the previous content is kept in filename+backupSuffix.
+//
+// A target that is not a regular file (a FIFO, /dev/stdout, a terminal) is
+// written in place instead, so it is not replaced by a regular file.
+func ReplaceFile(filename, content, backupSuffix string) error {
+	mode := os.FileMode(0644)
+	info, err := os.Stat(filename)
+	exists := err == nil
+	if exists && !info.Mode().IsRegular() {
+		return os.WriteFile(filename, []byte(content), mode)
+	}
+	if exists {
+		mode = info.Mode().Perm()
+		// replace the file a symlink points to, not the link itself
+		if filename, err = filepath.EvalSymlinks(filename); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	// best-effort cleanup; after a successful rename tmpName no longer exists
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if exists && backupSuffix != "" {
+		if err := copyFile(filename, filename+backupSuffix, mode); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpName, filename); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(filename))
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, mode)
+}
+
+// syncDir flushes the directory entry so the rename itself survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// some platforms/filesystems do not support syncing directories; the rename is done either way
+	_ = d.Sync()
+	return nil
+}
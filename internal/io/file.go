@@ -2,12 +2,24 @@ package io
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	stdio "io"
 	"os"
 	"strings"
 )
 
+// Stdio is the file name that stands for stdin (as input) or stdout (as output).
+const Stdio = "-"
+
 func ReadFile(filename string) (string, error) {
+	if filename == Stdio {
+		data, err := stdio.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return "", err
@@ -16,21 +28,70 @@ func ReadFile(filename string) (string, error) {
 }
 
 func WriteFile(filename, content string) error {
+	if filename == Stdio {
+		_, err := stdio.WriteString(os.Stdout, content)
+		return err
+	}
 	return os.WriteFile(filename, []byte(content), 0644)
 }
 
-func CheckOverwrite(filename string) error {
+// OverwritePolicy decides what CheckOverwrite does when the output file exists.
+type OverwritePolicy int
+
+const (
+	OverwritePrompt OverwritePolicy = iota // ask on stdin (only when stdin is a terminal)
+	OverwriteForce                         // --force: always overwrite
+	OverwriteNever                         // --no-clobber: never overwrite
+)
+
+// ErrCancelled reports that the output was left alone on purpose
+// (declined prompt, --no-clobber, or no terminal to ask on).
+var ErrCancelled = errors.New("cancelled")
+
+// CheckOverwrite returns nil if filename may be written.
+// Refusals wrap ErrCancelled; anything else is a real I/O error.
+func CheckOverwrite(filename string, policy OverwritePolicy) error {
+	// stdout is never "overwritten"
+	if filename == Stdio {
+		return nil
+	}
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		return nil
+	} else if err != nil {
+		return err
 	}
 
-	fmt.Print("Overwrite? (y/n): ")
+	switch policy {
+	case OverwriteForce:
+		return nil
+	case OverwriteNever:
+		return fmt.Errorf("%w: %s exists (--no-clobber)", ErrCancelled, filename)
+	}
+
+	if !IsTerminal(os.Stdin) {
+		return fmt.Errorf("%w: %s exists and stdin is not a terminal (use --force or --no-clobber)", ErrCancelled, filename)
+	}
+
+	fmt.Fprintf(os.Stderr, "Overwrite %s? (y/n): ", filename)
 	reader := bufio.NewReader(os.Stdin)
-	response, _ := reader.ReadString('\n')
+	response, err := reader.ReadString('\n')
+	if err != nil && err != stdio.EOF {
+		return err
+	}
+	if err == stdio.EOF && response == "" {
+		// e.g. the terminal was closed
+		return fmt.Errorf("%w: %s exists and no answer on stdin", ErrCancelled, filename)
+	}
 	response = strings.TrimSpace(strings.ToLower(response))
 
 	if response != "y" {
-		return fmt.Errorf("file exists")
+		return fmt.Errorf("%w: %s exists", ErrCancelled, filename)
 	}
 	return nil
 }
+
+// IsTerminal reports whether f is an interactive terminal. /dev/null and
+// other character devices are not.
+func IsTerminal(f *os.File) bool {
+	return isTerminal(f)
+}
@@ -0,0 +1,189 @@
+package io
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Input is one file found by ExpandInputs.
+// Rel is its path relative to the argument it came from, used to mirror the tree.
+type Input struct {
+	Path string
+	Rel  string
+}
+
+// ExpandInputs turns command-line arguments into a sorted, de-duplicated list of files.
+// A file named twice ("a.txt", "./a.txt", a link to it) is listed once.
+//
+//   - plain file        -> that file (Rel = base name)
+//   - directory         -> every file under it with extension ext (Rel = path inside dir)
+//   - glob (*, ?, [...]) -> matching files; "**" matches any number of directories
+//     (Rel = path below the glob's static prefix)
+func ExpandInputs(args []string, ext string) ([]Input, error) {
+	var out []Input
+	seen := map[string]bool{}           // absolute path with symlinks resolved
+	bySize := map[int64][]os.FileInfo{} // files added so far, for hard links
+	add := func(path, rel string) error {
+		key, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if real, err := filepath.EvalSymlinks(key); err == nil {
+			key = real
+		}
+		if seen[key] {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		for _, prev := range bySize[info.Size()] {
+			if os.SameFile(prev, info) {
+				return nil
+			}
+		}
+		seen[key] = true
+		bySize[info.Size()] = append(bySize[info.Size()], info)
+		out = append(out, Input{Path: path, Rel: rel})
+		return nil
+	}
+
+	for _, arg := range args {
+		if hasGlobMeta(arg) {
+			root, matches, err := expandGlob(arg)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("%s: no matching files", arg)
+			}
+			for _, m := range matches {
+				rel, _ := filepath.Rel(root, m)
+				if err := add(m, rel); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if err := add(arg, filepath.Base(arg)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !matchExt(path, ext) {
+				return nil
+			}
+			rel, _ := filepath.Rel(arg, path)
+			return add(path, rel)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// OutputPaths maps each input to its path under dir (dir/Rel). It fails before
+// anything is written if two inputs map to the same output, as a.txt and
+// b/a.txt do when both are given as plain files.
+func OutputPaths(inputs []Input, dir string) ([]string, error) {
+	outs := make([]string, len(inputs))
+	from := map[string]string{}
+	for i, in := range inputs {
+		out := filepath.Join(dir, in.Rel)
+		if prev, dup := from[out]; dup {
+			return nil, fmt.Errorf("%s and %s would both be written to %s", prev, in.Path, out)
+		}
+		from[out] = in.Path
+		outs[i] = out
+	}
+	return outs, nil
+}
+
+func matchExt(path, ext string) bool {
+	return ext == "" || strings.EqualFold(filepath.Ext(path), ext)
+}
+
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// expandGlob returns the static (meta-free) prefix directory of pattern and the
+// regular files matching it. Unlike filepath.Glob it understands "**".
+func expandGlob(pattern string) (string, []string, error) {
+	pattern = filepath.ToSlash(pattern)
+	parts := strings.Split(pattern, "/")
+
+	// static prefix: leading segments without glob meta
+	k := 0
+	for k < len(parts)-1 && !hasGlobMeta(parts[k]) {
+		k++
+	}
+	root := filepath.FromSlash(strings.Join(parts[:k], "/"))
+	if root == "" {
+		root = "."
+		if strings.HasPrefix(pattern, "/") {
+			root = "/"
+		}
+	}
+	rest := parts[k:]
+
+	var matches []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		if matchSegments(rest, strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	return root, matches, err
+}
+
+// matchSegments matches path segments against pattern segments, where a "**"
+// segment matches zero or more path segments.
+func matchSegments(pat, segs []string) bool {
+	if len(pat) == 0 {
+		return len(segs) == 0
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pat[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pat[0], segs[0]); !ok {
+		return false
+	}
+	return matchSegments(pat[1:], segs[1:])
+}
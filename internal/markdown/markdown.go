@@ -0,0 +1,347 @@
+// Package markdown runs the pipeline over the prose of a Markdown document only.
+//
+// Block structure (fenced and indented code, front matter, HTML blocks,
+// thematic breaks, table delimiter rows, link reference definitions, and the
+// heading, list, task and blockquote markers in front of a line) and inline
+// structure (code spans, autolinks, inline HTML, link destinations, emphasis
+// markers, escapes, entities, hard line breaks) are cut out with a markup.Doc
+// and restored byte-for-byte afterwards.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go-reloaded/internal/markup"
+)
+
+// Process runs process over the prose of the Markdown document text.
+func Process(text string, process func(string) string) (string, error) {
+	if err := markup.CheckInput(text); err != nil {
+		return "", err
+	}
+	return Split(text).Process(process)
+}
+
+var (
+	fenceRe      = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	breakRe      = regexp.MustCompile(`^ {0,3}([-*_])( *[-*_])+ *$`)
+	setextRe     = regexp.MustCompile(`^ {0,3}(=+|-+) *$`)
+	tableDelimRe = regexp.MustCompile(`^ *\|? *:?-+:? *(\| *:?-+:? *)*\|? *$`)
+	refDefRe     = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:`)
+	htmlBlockRe  = regexp.MustCompile(`^ {0,3}</?[A-Za-z][A-Za-z0-9-]*(\s|/?>|$)`)
+	prefixRe     = regexp.MustCompile(`^( *(>|[-*+]|\d{1,9}[.)])( +|$))+( *\[[ xX]\] +)?|^ *#{1,6}( +|$)`)
+	headingEndRe = regexp.MustCompile(` +#+ *$`)
+	hardBreakRe  = regexp.MustCompile(`( {2,}|\\)$`)
+	tableCellRe  = regexp.MustCompile(` *\| *`)
+	entityRe     = regexp.MustCompile(`^&(#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	autolinkRe   = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*|[^\s<>@]+@[^\s<>@]+)>`)
+	inlineHTMLRe = regexp.MustCompile(`^(<!--.*?-->|</?[A-Za-z][A-Za-z0-9-]*(\s[^<>]*)?/?>)`)
+)
+
+// Split separates the prose of a Markdown document from its markup.
+func Split(text string) *markup.Doc {
+	d := &markup.Doc{}
+	lines := strings.SplitAfter(text, "\n")
+
+	// front matter: a --- line first and a closing --- later
+	if len(lines) > 1 && isFrontMatterFence(lines[0]) {
+		for end := 1; end < len(lines); end++ {
+			if isFrontMatterFence(lines[end]) {
+				d.Opaque(strings.Join(lines[:end+1], ""))
+				lines = lines[end+1:]
+				break
+			}
+		}
+	}
+
+	var fence string   // the opening fence while inside a fenced code block
+	inComment := false // inside a multi-line <!-- --> block
+	inHTML := false    // inside an HTML block, which runs to the next blank line
+	prevBlank := true  // the previous line was blank (or there was none)
+	prevCode := false  // the previous line was indented code
+
+	for _, line := range lines {
+		body := strings.TrimSuffix(line, "\n")
+		eol := line[len(body):]
+		blank := strings.TrimSpace(body) == ""
+		indented := strings.HasPrefix(body, "    ") || strings.HasPrefix(body, "\t")
+
+		opaque, code := true, false
+		switch {
+		case fence != "":
+			if m := fenceRe.FindStringSubmatch(body); m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) && strings.TrimSpace(body[len(m[0]):]) == "" {
+				fence = ""
+			}
+		case inComment:
+			inComment = !strings.Contains(body, "-->")
+		case inHTML && !blank:
+		case fenceRe.MatchString(body):
+			fence = fenceRe.FindStringSubmatch(body)[1]
+		case blank:
+			opaque = false
+		case indented && (prevBlank || prevCode):
+			code = true
+		case strings.HasPrefix(strings.TrimLeft(body, " "), "<!--"):
+			inComment = !strings.Contains(body, "-->")
+		case breakRe.MatchString(body), setextRe.MatchString(body) && !prevBlank,
+			strings.Contains(body, "|") && tableDelimRe.MatchString(body),
+			refDefRe.MatchString(body):
+		case htmlBlockRe.MatchString(body):
+			inHTML = true
+		default:
+			opaque = false
+		}
+		inHTML = inHTML && !blank
+		prevBlank, prevCode = blank, code || (prevCode && blank)
+
+		if opaque {
+			d.Opaque(body)
+		} else {
+			splitLine(d, body)
+		}
+		d.Text(eol)
+	}
+	return d
+}
+
+// isFrontMatterFence reports whether line is "---" (trailing spaces allowed).
+func isFrontMatterFence(line string) bool {
+	return strings.TrimRight(strings.TrimSuffix(line, "\n"), " \r") == "---"
+}
+
+// splitLine splits one line of a paragraph, heading, list item, blockquote or
+// table row.
+func splitLine(d *markup.Doc, line string) {
+	prefix := prefixRe.FindString(line)
+	d.Opaque(prefix)
+	line = line[len(prefix):]
+
+	suffix := hardBreakRe.FindString(line)
+	if strings.Contains(prefix, "#") {
+		suffix = headingEndRe.FindString(line)
+	}
+	line = line[:len(line)-len(suffix)]
+
+	if strings.HasPrefix(strings.TrimLeft(line, " "), "|") {
+		// table row: every cell is prose on its own
+		last := 0
+		for _, m := range tableCellRe.FindAllStringIndex(line, -1) {
+			if escaped(line, m[0]) || inCodeSpan(line, m[0]) {
+				continue
+			}
+			splitInline(d, line[last:m[0]])
+			d.Opaque(line[m[0]:m[1]])
+			last = m[1]
+		}
+		splitInline(d, line[last:])
+	} else {
+		splitInline(d, line)
+	}
+	d.Opaque(suffix)
+}
+
+// escaped reports whether s[i] is preceded by an odd number of backslashes.
+func escaped(s string, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && s[j] == '\\'; j-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// inCodeSpan reports whether s[i] is inside a code span.
+func inCodeSpan(s string, i int) bool {
+	for j := 0; j < i; {
+		if s[j] != '`' || escaped(s, j) {
+			j++
+			continue
+		}
+		end := codeSpanEnd(s, j)
+		if end < 0 {
+			j += backtickRun(s, j)
+			continue
+		}
+		if i < end {
+			return true
+		}
+		j = end
+	}
+	return false
+}
+
+// backtickRun returns the length of the run of backticks at s[i].
+func backtickRun(s string, i int) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == '`' {
+		n++
+	}
+	return n
+}
+
+// codeSpanEnd returns the end of the code span opened by the backtick run at
+// s[i], or -1 if no run of the same length closes it.
+func codeSpanEnd(s string, i int) int {
+	n := backtickRun(s, i)
+	for j := i + n; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := backtickRun(s, j)
+		if m == n {
+			return j + m
+		}
+		j += m
+	}
+	return -1
+}
+
+// closingBracket returns the index of the ']' matching the '[' at s[i], or -1.
+func closingBracket(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch {
+		case s[j] == '\\':
+			j++
+		case s[j] == '`':
+			if end := codeSpanEnd(s, j); end > 0 {
+				j = end - 1
+			}
+		case s[j] == '[':
+			depth++
+		case s[j] == ']':
+			if depth--; depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// closingParen returns the index of the ')' matching the '(' at s[i], or -1.
+func closingParen(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// splitInline splits the inline content of a line.
+func splitInline(d *markup.Doc, s string) {
+	// linkEnds maps the ']' of each link to the end of its destination
+	linkEnds := map[int]int{}
+	text := 0 // start of pending prose
+	flush := func(i int) {
+		d.Text(s[text:i])
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		cut, isMarkup := 0, false // bytes to cut out at s[i], and how
+
+		switch {
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			cut = 2
+		case c == '`':
+			if end := codeSpanEnd(s, i); end > 0 {
+				cut = end - i
+			} else {
+				cut = backtickRun(s, i)
+			}
+		case c == '<':
+			if m := autolinkRe.FindString(s[i:]); m != "" {
+				cut = len(m)
+			} else if m := inlineHTMLRe.FindString(s[i:]); m != "" {
+				cut = len(m)
+			}
+		case c == '&':
+			cut = len(entityRe.FindString(s[i:]))
+		case c == '!' && i+1 < len(s) && s[i+1] == '[' || c == '[':
+			open := i
+			if c == '!' {
+				open++
+			}
+			if strings.HasPrefix(s[open:], "[^") {
+				// footnote reference
+				if end := closingBracket(s, open); end > 0 {
+					cut = end + 1 - i
+					break
+				}
+			}
+			if end := closingBracket(s, open); end > 0 && end+1 < len(s) {
+				switch s[end+1] {
+				case '(':
+					if p := closingParen(s, end+1); p > 0 {
+						linkEnds[end] = p + 1
+					}
+				case '[':
+					if p := strings.IndexByte(s[end+1:], ']'); p > 0 {
+						linkEnds[end] = end + 1 + p + 1
+					}
+				}
+			}
+			// "[" is markup around prose; "![" starts an image, not prose
+			cut, isMarkup = open+1-i, c == '['
+		case c == ']':
+			if end, ok := linkEnds[i]; ok {
+				cut = end - i
+			} else {
+				cut, isMarkup = 1, true
+			}
+		case c == '*' || c == '~':
+			// a run between spaces is a literal ("2 * 3"), not emphasis
+			n := runLength(s, i, c)
+			cut, isMarkup = n, !((i == 0 || s[i-1] == ' ') && (i+n == len(s) || s[i+n] == ' '))
+		case c == '_':
+			n := runLength(s, i, c)
+			before, _ := utf8.DecodeLastRuneInString(s[:i])
+			after, _ := utf8.DecodeRuneInString(s[i+n:])
+			if i == 0 || i+n == len(s) || !isWordRune(before) || !isWordRune(after) {
+				cut, isMarkup = n, true
+			}
+		}
+
+		if cut == 0 {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			i += size
+			continue
+		}
+		flush(i)
+		if isMarkup {
+			d.Markup(s[i : i+cut])
+		} else {
+			d.Opaque(s[i : i+cut])
+		}
+		i += cut
+		text = i
+	}
+	flush(len(s))
+}
+
+// runLength returns the length of the run of c at s[i].
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+func isWordRune(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+func isASCIIPunct(c byte) bool {
+	return c < 0x80 && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
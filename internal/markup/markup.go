@@ -0,0 +1,109 @@
+// Package markup runs the pipeline over the prose of a structured document
+// (Markdown, HTML) and leaves everything else byte-for-byte intact.
+//
+// A scanner splits the document into prose and cut-out spans with a Doc. Each
+// cut-out span becomes one placeholder rune in the prose (see
+// token.IsPlaceholder), which the tokenizer emits as a Protected token that no
+// transform touches. Restore swaps the spans back in after processing.
+package markup
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-reloaded/internal/token"
+)
+
+// ErrPlaceholder means the input already contains placeholder runes
+// (Unicode private use planes 15 and 16), so it cannot be split safely.
+var ErrPlaceholder = errors.New("markup: input contains private-use characters reserved for placeholders")
+
+// Doc is a document split into prose and cut-out spans.
+type Doc struct {
+	prose  strings.Builder
+	opaque []string
+	markup []string
+}
+
+// Text appends prose.
+func (d *Doc) Text(s string) { d.prose.WriteString(s) }
+
+// Opaque cuts out s (code, a URL, an HTML tag). It separates the words around
+// it: case tags, number tags and articles do not look past it.
+func (d *Doc) Opaque(s string) {
+	if s == "" {
+		return
+	}
+	d.prose.WriteRune(rune(token.OpaqueBase + len(d.opaque)))
+	d.opaque = append(d.opaque, s)
+}
+
+// Markup cuts out s (an emphasis marker, a link bracket). Unlike Opaque it
+// does not separate the words around it, so "**word** (up)" reaches the word.
+func (d *Doc) Markup(s string) {
+	if s == "" {
+		return
+	}
+	d.prose.WriteRune(rune(token.MarkupBase + len(d.markup)))
+	d.markup = append(d.markup, s)
+}
+
+// Prose returns the prose with placeholders, ready for the pipeline.
+func (d *Doc) Prose() (string, error) {
+	if len(d.opaque) > token.OpaqueMax-token.OpaqueBase+1 || len(d.markup) > token.MarkupMax-token.MarkupBase+1 {
+		return "", errors.New("markup: document has too many protected spans")
+	}
+	return d.prose.String(), nil
+}
+
+// Restore replaces the placeholders in processed prose with the spans they
+// stand for. It fails if a placeholder was lost, duplicated or reordered.
+func (d *Doc) Restore(processed string) (string, error) {
+	var sb strings.Builder
+	nextOpaque, nextMarkup := 0, 0
+	for _, r := range processed {
+		var span string
+		switch {
+		case r >= token.OpaqueBase && r <= token.OpaqueMax:
+			i := int(r - token.OpaqueBase)
+			if i != nextOpaque {
+				return "", fmt.Errorf("markup: protected span %d out of place", i)
+			}
+			span, nextOpaque = d.opaque[i], i+1
+		case r >= token.MarkupBase && r <= token.MarkupMax:
+			i := int(r - token.MarkupBase)
+			if i != nextMarkup {
+				return "", fmt.Errorf("markup: markup span %d out of place", i)
+			}
+			span, nextMarkup = d.markup[i], i+1
+		default:
+			sb.WriteRune(r)
+			continue
+		}
+		sb.WriteString(span)
+	}
+	if nextOpaque != len(d.opaque) || nextMarkup != len(d.markup) {
+		return "", errors.New("markup: protected spans were lost while processing")
+	}
+	return sb.String(), nil
+}
+
+// Process runs process over the prose of d and restores the cut-out spans.
+func (d *Doc) Process(process func(string) string) (string, error) {
+	prose, err := d.Prose()
+	if err != nil {
+		return "", err
+	}
+	return d.Restore(process(prose))
+}
+
+// CheckInput returns ErrPlaceholder if text contains placeholder runes.
+func CheckInput(text string) error {
+	for _, r := range text {
+		if token.IsPlaceholder(r) {
+			return ErrPlaceholder
+		}
+	}
+	return nil
+}
@@ -1,61 +1,305 @@
 package pipeline
 
 import (
+	"fmt"
+	"strings"
+
+	"go-reloaded/internal/diag"
 	"go-reloaded/internal/token"
 	"go-reloaded/internal/transform"
 )
 
-func ProcessText(in string) string {
-	toks := token.Tokenize(in)
+func init() {
+	for _, s := range []Stage{
+		StageFunc("validate-tags", transform.ValidateTags),
+		StageFunc("hex", transform.ApplyHex),
+		StageFunc("bin", transform.ApplyBin),
+		StageFunc("oct", transform.ApplyOct),
+		StageFunc("base", transform.ApplyBase),
+		StageFunc("to-hex", transform.ApplyToHex),
+		StageFunc("to-bin", transform.ApplyToBin),
+		StageFunc("to-oct", transform.ApplyToOct),
+		StageFunc("words", transform.ApplyWords),
+		StageFunc("num", transform.ApplyNum),
+		StageFunc("roman", transform.ApplyRoman),
+		StageFunc("from-roman", transform.ApplyFromRoman),
+		StageFunc("fmt", transform.ApplyFmt),
+		StageFunc("case", transform.ApplyCaseTags),
+		StageFunc("case-next", transform.ApplyCaseNextMarker),
+		StageFunc("articles", transform.ApplyArticleAn),
+		StageFunc("quote-pairs", transform.ApplyQuotes),
+		StageFunc("quote-spacing", transform.ApplyQuoteSpacingFix),
+		StageFunc("apostrophes", transform.ApplyApostropheSpacing),
+		StageFunc("space-after-quote", transform.ApplySpaceAfterClosingQuote),
+		StageFunc("space-before-quote", transform.ApplySpaceBeforeOpeningQuote),
+		StageFunc("dash-quote", transform.ApplyDashQuoteTight),
+		StageFunc("quote-edges", transform.ApplyTightenQuoteEdges),
+		StageFunc("final-spacing", transform.ApplyFinalSpacingFix),
+		StageFunc("spaces", transform.ApplySpaces),
+		StageFunc("trim-spaces", func(toks []token.Tok) []token.Tok { return transform.ApplySpacesWithTrim(toks, true) }),
+		StageFunc("punctuation", transform.ApplyPunctuation),
+		StageFunc("pronoun", transform.CapitalizeI),
+		StageFunc("drop-tags", transform.ApplyDropTags),
+	} {
+		mustRegister(s)
+	}
+
+	var err error
+	if defaultPipeline, err = New(Options{}); err != nil {
+		panic(err)
+	}
+}
 
+// DefaultOrder is the standard pipeline. A name may appear more than once.
+var DefaultOrder = []string{
 	// Validate tags (must have space before)
-	toks = transform.ValidateTags(toks)
+	"validate-tags",
 
 	// Numbers
-	toks = transform.ApplyHex(toks)
-	toks = transform.ApplyBin(toks)
+	"hex",
+	"bin",
+	"oct",
+	"base",
+	"to-hex",
+	"to-bin",
+	"to-oct",
+	"words", // before articles: "a 8 (words)" -> "an eight"
+	"num",
+	"roman",
+	"from-roman",
+	"fmt", // after the conversions: "ff (hex) (fmt, 2)"
 
 	// Case tags
-	toks = transform.ApplyCaseTags(toks)
+	"case",
 
 	// Articles (AFTER case transforms)
-	toks = transform.ApplyArticleAn(toks)
+	"articles",
 
 	// QUOTES
-	toks = transform.ApplyQuotes(toks)
-	toks = transform.ApplyQuotes(toks) // cheap second pass for tricky adjacencies
-	toks = transform.ApplyQuoteSpacingFix(toks) // Comprehensive quote spacing fix
-	toks = transform.ApplyApostropheSpacing(toks) // Fix apostrophe spacing
-	toks = transform.ApplySpaceAfterClosingQuote(toks)
-	toks = transform.ApplySpaceBeforeOpeningQuote(toks) // NEW
+	"quote-pairs",
+	"quote-pairs",   // cheap second pass for tricky adjacencies
+	"quote-spacing", // comprehensive quote spacing fix
+	"apostrophes",   // fix apostrophe spacing
+	"space-after-quote",
+	"space-before-quote",
 
 	// Normalize spaces, punctuation
-	toks = transform.ApplySpaces(toks)
-	toks = transform.ApplyPunctuation(toks)
+	"spaces",
+	"punctuation",
 
 	// CLEANUP / SPECIALS
-	toks = transform.ApplyDashQuoteTight(toks) // tighten —'quote' (remove space)
-	toks = transform.ApplyCaseNextMarker(toks) // transform next word after case-range tag
-	toks = transform.CapitalizeI(toks)         // capitalize personal pronoun "I"
-	toks = transform.ApplyDropTags(toks)
+	"dash-quote", // tighten —'quote' (remove space)
+	"case-next",  // transform next word after case-range tag
+	"pronoun",    // capitalize personal pronoun "I"
+	"drop-tags",
 
 	// FINAL: remove plain spaces flush against quote edges
-	toks = transform.ApplyTightenQuoteEdges(toks)
+	"quote-edges",
 	// Apply quotes multiple times to ensure all spacing is fixed
-	toks = transform.ApplyQuotes(toks)
-	toks = transform.ApplyQuotes(toks)
-	toks = transform.ApplyQuoteSpacingFix(toks)
+	"quote-pairs",
+	"quote-pairs",
+	"quote-spacing",
 
 	// Final sweep: collapse plain spaces and trim ends (preserve newlines)
-	toks = transform.ApplySpacesWithTrim(toks, true)
+	"trim-spaces",
 	// One more punctuation pass to fix any remaining spacing issues
-	toks = transform.ApplyPunctuation(toks)
-	// Final space cleanup
-	toks = transform.ApplySpacesWithTrim(toks, true)
+	"punctuation",
+	"trim-spaces",
 	// Final spacing fix for all edge cases - MUST BE LAST
-	toks = transform.ApplyFinalSpacingFix(toks)
-	// One more space cleanup after final fix
-	toks = transform.ApplySpacesWithTrim(toks, true)
+	"final-spacing",
+	"trim-spaces",
+}
+
+// Rules groups stages under the rule names used by Options.Disable and the
+// Skip* switches. Tag validation, spacing and dropping leftover tags are not
+// part of any rule: they keep the output well-formed.
+var Rules = map[string][]string{
+	"hex":         {"hex"},
+	"bin":         {"bin"},
+	"oct":         {"oct"},
+	"base":        {"base"},
+	"to-hex":      {"to-hex"},
+	"to-bin":      {"to-bin"},
+	"to-oct":      {"to-oct"},
+	"words":       {"words"},
+	"num":         {"num"},
+	"roman":       {"roman", "from-roman"},
+	"fmt":         {"fmt"},
+	"numbers":     {"hex", "bin", "oct", "base", "to-hex", "to-bin", "to-oct", "words", "num", "roman", "from-roman", "fmt"},
+	"case":        {"case", "case-next"},
+	"articles":    {"articles"},
+	"quotes":      {"quote-pairs", "quote-spacing", "apostrophes", "space-after-quote", "space-before-quote", "dash-quote", "quote-edges", "final-spacing"},
+	"punctuation": {"punctuation"},
+	"pronoun":     {"pronoun"},
+}
+
+// Options selects which stages run and in what order.
+// The zero value is the standard pipeline.
+type Options struct {
+	SkipHex         bool // (hex) conversion
+	SkipBin         bool // (bin) conversion
+	SkipCase        bool // (up), (low), (cap) and their (mode, n) forms
+	SkipArticles    bool // a/an correction
+	SkipQuotes      bool // quote and apostrophe spacing
+	SkipPunctuation bool // punctuation spacing
+	SkipPronoun     bool // capitalize "i"
+
+	// Stages replaces DefaultOrder when not nil; it may name custom registered stages.
+	Stages []string
+	// Disable drops stages by stage name or by rule name (see Rules).
+	Disable []string
+
+	// Rule parameters; nil (or zero) keeps the built-in behaviour.
+	Articles    *transform.ArticleRules
+	Case        transform.CaseRules
+	Punctuation *transform.PunctRules
+}
+
+// configured returns the stages whose behaviour opts parameterizes; they take
+// precedence over the registry entries of the same name.
+func (opts Options) configured() map[string]Stage {
+	m := map[string]Stage{}
+	if opts.Articles != nil {
+		rules := *opts.Articles
+		m["articles"] = StageFunc("articles", func(toks []token.Tok) []token.Tok {
+			return transform.ApplyArticleAnWith(toks, rules)
+		})
+	}
+	if opts.Case != (transform.CaseRules{}) {
+		rules := opts.Case
+		m["case"] = StageFunc("case", func(toks []token.Tok) []token.Tok {
+			return transform.ApplyCaseTagsWith(toks, rules)
+		})
+	}
+	if opts.Punctuation != nil {
+		rules := *opts.Punctuation
+		m["punctuation"] = StageFunc("punctuation", func(toks []token.Tok) []token.Tok {
+			return transform.ApplyPunctuationWith(toks, rules)
+		})
+	}
+	return m
+}
+
+// Pipeline is a resolved, ordered list of stages. It is safe for concurrent use
+// as long as its stages are.
+type Pipeline struct {
+	stages    []Stage
+	caseRules transform.CaseRules
+}
+
+var defaultPipeline *Pipeline
+
+// New resolves opts against the registry. Unknown stage or rule names are errors.
+func New(opts Options) (*Pipeline, error) {
+	order := opts.Stages
+	if order == nil {
+		order = DefaultOrder
+	}
+
+	disabled := map[string]bool{}
+	disable := func(name string) error {
+		if stages, ok := Rules[name]; ok {
+			for _, s := range stages {
+				disabled[s] = true
+			}
+			return nil
+		}
+		if _, ok := Lookup(name); !ok {
+			return fmt.Errorf("pipeline: unknown stage or rule %q", name)
+		}
+		disabled[name] = true
+		return nil
+	}
+	for rule, skip := range map[string]bool{
+		"hex":         opts.SkipHex,
+		"bin":         opts.SkipBin,
+		"case":        opts.SkipCase,
+		"articles":    opts.SkipArticles,
+		"quotes":      opts.SkipQuotes,
+		"punctuation": opts.SkipPunctuation,
+		"pronoun":     opts.SkipPronoun,
+	} {
+		if skip {
+			disable(rule)
+		}
+	}
+	for _, name := range opts.Disable {
+		if err := disable(name); err != nil {
+			return nil, err
+		}
+	}
 
+	configured := opts.configured()
+	p := &Pipeline{caseRules: opts.Case}
+	for _, name := range order {
+		s, ok := configured[name]
+		if !ok {
+			s, ok = Lookup(name)
+		}
+		if !ok {
+			return nil, fmt.Errorf("pipeline: unknown stage %q", name)
+		}
+		if !disabled[name] {
+			p.stages = append(p.stages, s)
+		}
+	}
+	return p, nil
+}
+
+// Stages returns the stages p runs, in order.
+func (p *Pipeline) Stages() []Stage {
+	return append([]Stage(nil), p.stages...)
+}
+
+// Process tokenizes in, runs every stage and joins the result.
+func (p *Pipeline) Process(in string) string {
+	toks := token.Tokenize(in)
+	for _, s := range p.stages {
+		toks = s.Apply(toks)
+	}
+	return token.Join(toks)
+}
+
+// ProcessTrace is Process that calls trace after every stage with the stage's
+// input and output. before is a private copy, so stages that edit in place
+// do not change what trace sees.
+func (p *Pipeline) ProcessTrace(in string, trace func(stage string, before, after []token.Tok)) string {
+	toks := token.Tokenize(in)
+	for _, s := range p.stages {
+		before := append([]token.Tok(nil), toks...)
+		toks = s.Apply(toks)
+		trace(s.Name(), before, toks)
+	}
 	return token.Join(toks)
 }
+
+// Lint reports what transform.Lint finds in text, leaving out the rules of
+// stages p does not run: "case/..." goes away when the case rule is disabled.
+func (p *Pipeline) Lint(text string) []diag.Diagnostic {
+	return p.ownRules(transform.Lint(token.Tokenize(text), p.caseRules))
+}
+
+// LintNumbers is transform.LintNumbers over text, filtered like Lint.
+func (p *Pipeline) LintNumbers(text string) []diag.Diagnostic {
+	return p.ownRules(transform.LintNumbers(token.Tokenize(text)))
+}
+
+// ownRules keeps the diagnostics whose rule belongs to a stage p runs.
+func (p *Pipeline) ownRules(ds []diag.Diagnostic) []diag.Diagnostic {
+	runs := map[string]bool{}
+	for _, s := range p.stages {
+		runs[s.Name()] = true
+	}
+	var own []diag.Diagnostic
+	for _, d := range ds {
+		if stage, _, _ := strings.Cut(d.Rule, "/"); runs[stage] {
+			own = append(own, d)
+		}
+	}
+	return own
+}
+
+// ProcessText runs the standard pipeline.
+func ProcessText(in string) string {
+	return defaultPipeline.Process(in)
+}
@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go-reloaded/internal/token"
+)
+
+// Stage is one pass over the token stream.
+// Apply may modify and return its argument or build a new slice.
+type Stage interface {
+	Name() string
+	Apply([]token.Tok) []token.Tok
+}
+
+type funcStage struct {
+	name string
+	fn   func([]token.Tok) []token.Tok
+}
+
+func (s funcStage) Name() string                       { return s.name }
+func (s funcStage) Apply(toks []token.Tok) []token.Tok { return s.fn(toks) }
+
+// StageFunc wraps a plain transform function as a named Stage.
+func StageFunc(name string, fn func([]token.Tok) []token.Tok) Stage {
+	return funcStage{name: name, fn: fn}
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Stage{}
+)
+
+// Register makes s available by name to pipelines built afterwards.
+// Names must be unique; registering a taken name is an error.
+func Register(s Stage) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	name := s.Name()
+	if name == "" {
+		return fmt.Errorf("pipeline: stage with empty name")
+	}
+	if _, dup := registry[name]; dup {
+		return fmt.Errorf("pipeline: stage %q already registered", name)
+	}
+	registry[name] = s
+	return nil
+}
+
+func mustRegister(s Stage) {
+	if err := Register(s); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the registered stage called name.
+func Lookup(name string) (Stage, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	s, ok := registry[name]
+	return s, ok
+}
+
+// Names lists every registered stage, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
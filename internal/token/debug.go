@@ -1,12 +1,39 @@
 package token
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+var kindNames = [...]string{
+	Word:      "Word",
+	Space:     "Space",
+	Quote:     "Quote",
+	Punct:     "Punct",
+	Group:     "Group",
+	Tag:       "Tag",
+	Protected: "Protected",
+	Symbol:    "Symbol",
+}
+
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
 
 func DebugDump(toks []Tok, limit int) {
+	Fdump(os.Stdout, toks, limit)
+}
+
+// Fdump is DebugDump writing to w.
+func Fdump(w io.Writer, toks []Tok, limit int) {
 	if limit <= 0 || limit > len(toks) {
 		limit = len(toks)
 	}
 	for i := 0; i < limit; i++ {
-		fmt.Printf("%3d: %-6v %q\n", i, toks[i].K, toks[i].Text)
+		fmt.Fprintf(w, "%3d: %-6v %q\n", i, toks[i].K, toks[i].Text)
 	}
 }
@@ -0,0 +1,214 @@
+package token
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Protected spans are URLs, email addresses and file paths. The tokenizer
+// emits each as one Protected token, which transforms pass through untouched:
+//
+//	https://example.com/a/b.html   www.example.com/x   mailto:me@example.com
+//	me@example.com                 ./internal/io/file.go   ~/notes   /usr/bin
+//	internal/io/file.go            C:\Users\me
+//
+// Trailing sentence punctuation ("see ./a.go.") and an unbalanced closing
+// bracket ("(at https://x.org)") are not part of the span.
+
+// Placeholder runes stand for content a caller cut out of the text before
+// tokenizing, such as Markdown code (see internal/markup). Each is one
+// Protected token. Opaque placeholders separate the words around them; markup
+// placeholders (emphasis markers, link brackets) do not, so "**word** (up)"
+// still reaches the word.
+const (
+	OpaqueBase = 0xF0000 // Unicode plane 15, private use
+	OpaqueMax  = 0xFFFFD
+	MarkupBase = 0x100000 // Unicode plane 16, private use
+	MarkupMax  = 0x10FFFD
+)
+
+// IsPlaceholder reports whether r is an opaque or markup placeholder.
+func IsPlaceholder(r rune) bool {
+	return r >= OpaqueBase && r <= OpaqueMax || r >= MarkupBase && r <= MarkupMax
+}
+
+// Placeholder reports whether t is an opaque or markup placeholder.
+func (t Tok) Placeholder() bool {
+	r := []rune(t.Text)
+	return t.K == Protected && len(r) == 1 && IsPlaceholder(r[0])
+}
+
+// IsMarkup reports whether t is a markup placeholder.
+func IsMarkup(t Tok) bool {
+	if t.K != Protected {
+		return false
+	}
+	r := []rune(t.Text)
+	return len(r) == 1 && r[0] >= MarkupBase && r[0] <= MarkupMax
+}
+
+// scanProtected returns the end of the protected span starting at r[i], or i
+// if there is none. A span only starts where a word could.
+func scanProtected(r []rune, i int) int {
+	if i > 0 && !isSpanBoundary(r[i-1]) {
+		return i
+	}
+	end := i
+	for end < len(r) && !isSpanBreak(r[end]) {
+		end++
+	}
+	cand := trimSpanEnd(string(r[i:end]))
+	if !isURL(cand) && !isEmail(cand) && !isPath(cand) {
+		return i
+	}
+	return i + len([]rune(cand))
+}
+
+// ContainsProtected reports whether s holds a URL, email address or path
+// anywhere, including where the tokenizer would not start a span
+// ("(see:https://x.org)").
+func ContainsProtected(s string) bool {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return isSpanBreak(r) || strings.ContainsRune("(),;", r)
+	})
+	for _, f := range fields {
+		prev := ' '
+		for i, c := range f {
+			if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) && !strings.ContainsRune("/\\.~_-", prev) {
+				cand := trimSpanEnd(f[i:])
+				if isURL(cand) || isEmail(cand) || isPath(cand) {
+					return true
+				}
+			}
+			prev = c
+		}
+	}
+	return false
+}
+
+// isSpanBoundary reports whether a span may start right after r.
+func isSpanBoundary(r rune) bool {
+	switch r {
+	case '(', '[', '<', '"', '\'', '—':
+		return true
+	}
+	return unicode.IsSpace(r) || IsPlaceholder(r)
+}
+
+// isSpanBreak reports whether r ends a span candidate.
+func isSpanBreak(r rune) bool {
+	switch r {
+	case '"', '<', '>', '`':
+		return true
+	}
+	return unicode.IsSpace(r) || IsPlaceholder(r)
+}
+
+// trimSpanEnd drops trailing sentence punctuation, quotes and unbalanced
+// closing brackets from a span candidate.
+func trimSpanEnd(s string) string {
+	for s != "" {
+		last := s[len(s)-1]
+		switch {
+		case strings.IndexByte(".,;:!?'", last) >= 0:
+			s = s[:len(s)-1]
+		case last == ')' && strings.Count(s, ")") > strings.Count(s, "("):
+			s = s[:len(s)-1]
+		case last == ']' && strings.Count(s, "]") > strings.Count(s, "["):
+			s = s[:len(s)-1]
+		default:
+			return s
+		}
+	}
+	return s
+}
+
+// isURL reports whether s is "scheme://rest", "mailto:..." or "www.host...".
+func isURL(s string) bool {
+	lower := strings.ToLower(s)
+	if rest, ok := strings.CutPrefix(lower, "www."); ok {
+		return isHost(strings.SplitN(rest, "/", 2)[0])
+	}
+	if rest, ok := strings.CutPrefix(lower, "mailto:"); ok {
+		return isEmail(rest)
+	}
+	scheme, rest, ok := strings.Cut(lower, "://")
+	if !ok || scheme == "" || rest == "" {
+		return false
+	}
+	for i, c := range scheme {
+		alpha := c >= 'a' && c <= 'z'
+		if !alpha && (i == 0 || !(c >= '0' && c <= '9' || c == '+' || c == '.' || c == '-')) {
+			return false
+		}
+	}
+	return true
+}
+
+// isHost reports whether s looks like "example.com": dot-separated labels of
+// letters, digits and hyphens, with at least two labels.
+func isHost(s string) bool {
+	labels := strings.Split(s, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, l := range labels {
+		if l == "" {
+			return false
+		}
+		for _, c := range l {
+			if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isEmail reports whether s is "local@host.tld".
+func isEmail(s string) bool {
+	local, host, ok := strings.Cut(s, "@")
+	if !ok || local == "" || strings.ContainsRune(host, '@') {
+		return false
+	}
+	for _, c := range local {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune(".!#$%&'*+/=?^_{|}~-", c) {
+			return false
+		}
+	}
+	return isHost(host)
+}
+
+// isPath reports whether s is a file path: it starts with "./", "../", "~/",
+// "/" or a drive letter ("C:\"), or it has at least two segments and its last
+// segment has an extension ("internal/io/file.go").
+func isPath(s string) bool {
+	for _, c := range s {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune("/\\._-~+@:", c) {
+			return false
+		}
+	}
+	switch {
+	case strings.HasPrefix(s, "./"), strings.HasPrefix(s, "../"), strings.HasPrefix(s, "~/"):
+		return len(s) > strings.IndexByte(s, '/')+1
+	case strings.HasPrefix(s, "/"):
+		return len(s) > 1 && (unicode.IsLetter(rune(s[1])) || unicode.IsDigit(rune(s[1])) || s[1] == '.' || s[1] == '_')
+	case len(s) > 3 && s[1] == ':' && s[2] == '\\' && unicode.IsLetter(rune(s[0])):
+		return true
+	}
+	slash := strings.LastIndexByte(s, '/')
+	if slash <= 0 || strings.IndexByte(s, ':') >= 0 {
+		return false
+	}
+	base := s[slash+1:]
+	dot := strings.LastIndexByte(base, '.')
+	if dot <= 0 || dot == len(base)-1 {
+		return false
+	}
+	for _, c := range base[dot+1:] {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return true
+}
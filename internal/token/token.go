@@ -13,27 +13,58 @@ const (
 	Punct
 	Group
 	Tag
+	Protected // URL, email address or file path; transforms leave it alone
+	Symbol    // anything else: "&", "%", "$", "*", "[", "…", emoji, an unmatched ")"
 )
 
+// Tok is one token. Off (byte offset), Line and Col (1-based, Col counted in
+// runes) locate it in the original input. Tokens made up by transforms have no
+// source position (Line == 0); see Synthetic. A token keeps its position when a
+// transform only rewrites its Text or Kind.
 type Tok struct {
 	K    Kind
 	Text string
+
+	Off  int
+	Line int
+	Col  int
 }
 
+// Synthetic reports whether t was inserted by a transform rather than read from the input.
+func (t Tok) Synthetic() bool { return t.Line == 0 }
+
 // Alias for compatibility
 type Token = Tok
 type TokenType = Kind
 
 // Tokenize scans the input once and emits meaningful tokens.
-// It avoids regex pitfalls and never mutates/"masks" content.
+// It avoids regex pitfalls and never mutates/"masks" content: every byte of s
+// ends up in exactly one token, so Join(Tokenize(s)) == s, even for invalid UTF-8.
 func Tokenize(s string) []Tok {
 	r := []rune(s)
 	var out []Tok
 	i := 0
 	n := len(r)
 
+	// source position of every rune index (and of the end);
+	// ranging over s keeps byte offsets right even for invalid UTF-8
+	type pos struct{ off, line, col int }
+	at := make([]pos, n+1)
+	line, col, k := 1, 1, 0
+	for off, rr := range s {
+		at[k] = pos{off, line, col}
+		k++
+		if rr == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	at[n] = pos{len(s), line, col}
+
 	emit := func(k Kind, start, end int) {
-		out = append(out, Tok{K: k, Text: string(r[start:end])})
+		out = append(out, Tok{K: k, Text: s[at[start].off:at[end].off], Off: at[start].off, Line: at[start].line, Col: at[start].col})
 	}
 
 	isWordRune := func(rr rune) bool {
@@ -52,6 +83,18 @@ func Tokenize(s string) []Tok {
 	for i < n {
 		ch := r[i]
 
+		// 0) Protected span: placeholder, URL, email address or file path
+		if IsPlaceholder(ch) {
+			emit(Protected, i, i+1)
+			i++
+			continue
+		}
+		if end := scanProtected(r, i); end > i {
+			emit(Protected, i, end)
+			i = end
+			continue
+		}
+
 		// 1) Quote (apostrophes and double quotes)
 		if ch == '\'' {
 			leftWord := i-1 >= 0 && isWordRune(r[i-1])
@@ -113,11 +156,12 @@ func Tokenize(s string) []Tok {
 			continue
 		}
 
-		// 5) Tag: any balanced (...) — classify as a Tag token
+		// 5) Tag: any balanced (...) — classify as a Tag token, unless a
+		// protected span starts inside it ("(at https://x.org)")
 		if ch == '(' {
 			start := i
 			j := i + 1
-			for j < n && r[j] != ')' {
+			for j < n && r[j] != ')' && !IsPlaceholder(r[j]) && scanProtected(r, j) == j {
 				j++
 			}
 			if j < n && r[j] == ')' {
@@ -129,9 +173,22 @@ func Tokenize(s string) []Tok {
 			// No closing ')': fall through to word scan
 		}
 
-		// 6) Word: consume word runes and embedded apostrophes
-		if isWordRune(ch) {
+		// 6) Word: consume word runes and embedded apostrophes.
+		// A leading sign or '#' glued to a word belongs to it ("-1A", "+5", "#ff").
+		signed := (ch == '-' || ch == '+' || ch == '#') && i+1 < n && isWordRune(r[i+1]) && (i == 0 || !isWordRune(r[i-1]))
+		if isWordRune(ch) || signed {
 			start := i
+			if signed {
+				i++
+			}
+			// a numeric literal ("1,000", "-3.14", "10:30", "v1.2.3") is one
+			// Word, and so is a suffix glued to it ("10:30am", "1.2.3-rc1")
+			version := (ch == 'v' || ch == 'V') && (i == 0 || !isWordRune(r[i-1]))
+			if version && scanNumber(r, i+1) > i+1 {
+				i = scanNumber(r, i+1)
+			} else if end := scanNumber(r, i); end > i {
+				i = end
+			}
 			for i < n {
 				c := r[i]
 				if isWordRune(c) {
@@ -143,9 +200,9 @@ func Tokenize(s string) []Tok {
 					i++ // consume apostrophe as part of word
 					continue
 				}
-				// allow hyphen inside a word between word runes
-				if c == '-' && i+1 < n && i-1 >= start && isWordRune(r[i-1]) && isWordRune(r[i+1]) {
-					i++ // consume hyphen as part of word
+				// allow hyphen or underscore inside a word between word runes ("1010_0101")
+				if (c == '-' || c == '_') && i+1 < n && i-1 >= start && isWordRune(r[i-1]) && isWordRune(r[i+1]) {
+					i++ // consume hyphen/underscore as part of word
 					continue
 				}
 				break
@@ -154,13 +211,97 @@ func Tokenize(s string) []Tok {
 			continue
 		}
 
-		// 7) Fallback: consume one rune to avoid infinite loop
-		i++
+		// 7) Symbol: a run of runes none of the above claims, including an
+		// unmatched "(" or ")"
+		start := i
+		for i++; i < n && isSymbol(r, i, isWordRune, isPunct); i++ {
+		}
+		emit(Symbol, start, i)
 	}
 
 	return out
 }
 
+// isSymbol reports whether r[i] would be emitted as part of a Symbol: it starts
+// no other token.
+func isSymbol(r []rune, i int, isWordRune, isPunct func(rune) bool) bool {
+	c := r[i]
+	switch {
+	case isWordRune(c), isPunct(c), unicode.IsSpace(c), IsPlaceholder(c):
+		return false
+	case c == '\'', c == '"', c == '.', c == '!', c == '?', c == '(':
+		return false
+	case (c == '-' || c == '+' || c == '#') && i+1 < len(r) && isWordRune(r[i+1]):
+		// a sign glued to a word
+		return false
+	}
+	return scanProtected(r, i) == i
+}
+
+// groupSpace is the narrow no-break space that groups French numbers
+// ("1 234 567,5").
+const groupSpace = '\u202f'
+
+// isGroupMark reports whether c can group the digits of a number.
+func isGroupMark(c rune) bool {
+	return c == ',' || c == groupSpace || c == '\'' || c == '_'
+}
+
+// scanNumber returns the end of the numeric literal starting at r[i], or i if
+// there is none. A numeric literal is a grouped number ("1,000", "1 000",
+// "1'000", "1_000") or digit runs joined by single dots and colons: decimals
+// ("3.14"), times ("10:30:15"), versions ("1.2.3"), IP addresses
+// ("192.168.0.1") and ratios ("16:9"). A decimal comma follows an ungrouped
+// number or one grouped by dots or spaces ("3,14159", "1.234,50", "1 234,5"),
+// so everything (fmt) writes reads back as one Word. Groups after a group mark
+// must have exactly three digits, a comma followed by more digits never ends a
+// literal (that is a list: "1,5,7"), and a literal never starts in the middle
+// of another one.
+func scanNumber(r []rune, i int) int {
+	isDigit := func(j int) bool { return j >= 0 && j < len(r) && r[j] >= '0' && r[j] <= '9' }
+	digits := func(j int) int {
+		for isDigit(j) {
+			j++
+		}
+		return j
+	}
+
+	j := digits(i)
+	if j == i {
+		return i
+	}
+	if i >= 2 && (r[i-1] == '.' || r[i-1] == ':' || isGroupMark(r[i-1])) && isDigit(i-2) {
+		// the tail of something like "1,2"
+		return i
+	}
+	separated := false
+	var group rune // the group mark once the integer part is grouped
+	if j-i <= 3 {
+		for j < len(r) && isGroupMark(r[j]) && (group == 0 || r[j] == group) && digits(j+1) == j+4 {
+			group, j = r[j], j+4
+			separated = true
+		}
+	}
+	// dotGroups: so far the literal is grouped by dots ("1.234.567")
+	dotGroups := group == 0 && j-i <= 3
+	dots := 0
+	for j < len(r) && (r[j] == '.' || r[j] == ':') && isDigit(j+1) {
+		end := digits(j + 1)
+		dotGroups = dotGroups && r[j] == '.' && end-j == 4
+		j, dots = end, dots+1
+		separated = true
+	}
+	if j < len(r) && r[j] == ',' && isDigit(j+1) && (group == groupSpace || group == 0 && (dots == 0 || dotGroups)) {
+		// decimal comma
+		j = digits(j + 1)
+		separated = true
+	}
+	if !separated || (j < len(r) && r[j] == ',' && isDigit(j+1)) {
+		return i
+	}
+	return j
+}
+
 // Join concatenates tokens back into a string exactly as stored in tokens.
 // (Spacing/punctuation rules are handled by transforms, not here.)
 func Join(toks []Tok) string {
@@ -1,12 +1,33 @@
 package transform
 
 import (
+	"regexp"
 	"strings"
 
 	"go-reloaded/internal/token"
 )
 
+// ArticleRules lists word prefixes whose pronunciation does not follow the
+// spelling: An prefixes take "an" despite a consonant letter (silent h),
+// A prefixes take "a" despite a vowel letter ("uni", "one").
+// The longest matching prefix wins.
+type ArticleRules struct {
+	An []string
+	A  []string
+}
+
+// DefaultArticleRules are the built-in exceptions.
+var DefaultArticleRules = ArticleRules{
+	An: []string{"hour", "honest", "honor", "heir", "herb"},
+	A:  []string{"uni", "eu", "one"},
+}
+
 func ApplyArticleAn(toks []token.Tok) []token.Tok {
+	return ApplyArticleAnWith(toks, DefaultArticleRules)
+}
+
+// ApplyArticleAnWith is ApplyArticleAn with custom exception lists.
+func ApplyArticleAnWith(toks []token.Tok, rules ArticleRules) []token.Tok {
 	out := make([]token.Tok, 0, len(toks))
 	for i := 0; i < len(toks); i++ {
 		t := toks[i]
@@ -15,6 +36,10 @@ func ApplyArticleAn(toks []token.Tok) []token.Tok {
 			if article == "a" || article == "an" {
 				nextWordIdx := -1
 				for j := i + 1; j < len(toks); j++ {
+					if isBarrier(toks[j]) {
+						// "a https://..." is left as written
+						break
+					}
 					if toks[j].K == token.Word {
 						nextWordIdx = j
 						break
@@ -22,7 +47,7 @@ func ApplyArticleAn(toks []token.Tok) []token.Tok {
 				}
 				if nextWordIdx != -1 {
 					nextWord := toks[nextWordIdx].Text
-					shouldBeAn := needsAn(nextWord)
+					shouldBeAn := needsAn(nextWord, rules)
 					if shouldBeAn && article == "a" {
 						if t.Text == "a" {
 							t.Text = "an"
@@ -44,56 +69,50 @@ func ApplyArticleAn(toks []token.Tok) []token.Tok {
 	return out
 }
 
-func needsAn(word string) bool {
+// spokenNumberRe matches the numbers needsAn reads aloud: a bare integer,
+// optionally with an ordinal suffix.
+var spokenNumberRe = regexp.MustCompile(`^([0-9]+)(st|nd|rd|th)?$`)
+
+func needsAn(word string, rules ArticleRules) bool {
 	if len(word) == 0 {
 		return false
 	}
 
 	lower := strings.ToLower(word)
-	first := lower[0]
 
-	// Special cases: words starting with vowel letters but consonant sounds
-	if first == 'u' {
-		// "university", "european", "unicorn" etc. start with 'y' sound
-		if strings.HasPrefix(lower, "uni") || strings.HasPrefix(lower, "eu") {
-			return false // keep "a"
+	// Bare integers and ordinals are read aloud: "an 8", "an 11th", "a 100"
+	if m := spokenNumberRe.FindStringSubmatch(lower); m != nil {
+		if spelled, why := numberToWords(m[1]); why == "" {
+			lower = spelled
 		}
-		return true // "umbrella", "uncle" need "an"
 	}
 
-	// Standard vowels (except special cases)
-	if first == 'a' || first == 'i' {
-		return true
+	// Exceptions: "hour" -> an (silent 'h'), "university"/"european"/"one" -> a ('y'/'w' sound)
+	anLen := longestPrefix(lower, rules.An)
+	aLen := longestPrefix(lower, rules.A)
+	if anLen > 0 || aLen > 0 {
+		return anLen >= aLen
 	}
 
-	if first == 'e' {
-		// "European" starts with 'y' sound
-		if strings.HasPrefix(lower, "eu") {
-			return false // keep "a"
-		}
+	// Standard vowels
+	switch lower[0] {
+	case 'a', 'e', 'i', 'o', 'u':
 		return true
 	}
+	return false
+}
 
-	// Silent 'h' words need "an"
-	if first == 'h' {
-		silentH := []string{"hour", "honest", "honor", "heir", "herb"}
-		for _, word := range silentH {
-			if strings.HasPrefix(lower, word) {
-				return true
-			}
-		}
-	}
-
-	// Numbers starting with vowel sounds but written as consonants
-	if first == 'o' {
-		// "one" starts with 'w' sound
-		if strings.HasPrefix(lower, "one") {
-			return false // keep "a"
+// longestPrefix returns the length of the longest entry of prefixes that word
+// starts with, or 0.
+func longestPrefix(word string, prefixes []string) int {
+	best := 0
+	for _, p := range prefixes {
+		p = strings.ToLower(p)
+		if len(p) > best && strings.HasPrefix(word, p) {
+			best = len(p)
 		}
-		return true
 	}
-
-	return false
+	return best
 }
 
 func preserveCase(newWord, oldWord string) string {
@@ -4,14 +4,26 @@ import (
 	"strings"
 	"unicode"
 
+	"go-reloaded/internal/diag"
 	"go-reloaded/internal/token"
 )
 
 const caseNextMarkerPrefix = "CASE_NEXT:"
 
+// CaseRules tunes ApplyCaseTagsWith.
+// MaxRange caps n in (mode, n); larger counts make the tag malformed. 0 means no limit.
+type CaseRules struct {
+	MaxRange int
+}
+
 // ApplyCaseTags updates words affected by (up), (low), (cap) and their (mode, n) forms.
 // It applies to the LAST n previous Word tokens (skip Space/Quote/Punct/Group), not including the tag itself.
 func ApplyCaseTags(toks []token.Tok) []token.Tok {
+	return ApplyCaseTagsWith(toks, CaseRules{})
+}
+
+// ApplyCaseTagsWith is ApplyCaseTags with a configurable range limit.
+func ApplyCaseTagsWith(toks []token.Tok, rules CaseRules) []token.Tok {
 	out := make([]token.Tok, 0, len(toks))
 
 	for i := 0; i < len(toks); i++ {
@@ -22,6 +34,9 @@ func ApplyCaseTags(toks []token.Tok) []token.Tok {
 		}
 
 		mode, n, kind := parseCaseTagTri(t.Text)
+		if kind == caseOK && rules.MaxRange > 0 && n > rules.MaxRange {
+			kind = caseMalformed
+		}
 		switch kind {
 		case caseUnknown:
 			// Not a case tag → keep for other transforms (hex/bin) or drop later
@@ -101,23 +116,35 @@ func parseCaseTagTri(s string) (mode string, n int, kind caseKind) {
 	// default n = 1
 	n = 1
 	if len(parts) > 1 {
-		numStr := strings.TrimSpace(parts[1])
-		val := 0
-		if numStr == "" {
+		var ok bool
+		if n, ok = parseTagCount(parts[1]); !ok {
 			return "", 0, caseMalformed
 		}
-		for _, r := range numStr {
-			if r < '0' || r > '9' {
-				return "", 0, caseMalformed
-			}
-			val = val*10 + int(r-'0')
-		}
-		// allow n == 0 (no-op)
-		n = val
 	}
 	return mode, n, caseOK
 }
 
+// parseTagCount parses the n of a "(mode, n)" tag: decimal digits only,
+// surrounding spaces allowed. n == 0 is valid (a no-op).
+func parseTagCount(s string) (int, bool) {
+	numStr := strings.TrimSpace(s)
+	val := 0
+	if numStr == "" {
+		return 0, false
+	}
+	for _, r := range numStr {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+		val = val*10 + int(r-'0')
+		if val > 1<<20 {
+			// far more words than any line has; avoid overflow
+			return 0, false
+		}
+	}
+	return val, true
+}
+
 func capWord(s string) string {
 	parts := strings.Split(s, "-")
 	for i, p := range parts {
@@ -159,8 +186,8 @@ func collectPreviousWordIdxsSameLine(toks []token.Tok, i, n int) []int {
 			idxs = append(idxs, j)
 			seen++
 		}
-		// Stop only at NEWLINES (not punctuation or spaces)
-		if toks[j].K == token.Group && toks[j].Text == "\n" {
+		// Stop only at NEWLINES and protected spans (not punctuation or spaces)
+		if isBarrier(toks[j]) || toks[j].K == token.Group && toks[j].Text == "\n" {
 			break
 		}
 	}
@@ -170,3 +197,26 @@ func collectPreviousWordIdxsSameLine(toks []token.Tok, i, n int) []int {
 	}
 	return idxs
 }
+
+// lintCaseTag checks a case tag at toks[i]; ok is false for other tags.
+func lintCaseTag(toks []token.Tok, i int, rules CaseRules) (ds []diag.Diagnostic, ok bool) {
+	t := toks[i]
+	_, n, kind := parseCaseTagTri(t.Text)
+	switch {
+	case kind == caseUnknown:
+		return nil, false
+	case kind == caseMalformed:
+		return []diag.Diagnostic{diagAt(t, "case/malformed", "%s is not a valid case tag (want (up), (low), (cap) or (mode, n)); it is removed", t.Text)}, true
+	case rules.MaxRange > 0 && n > rules.MaxRange:
+		return []diag.Diagnostic{diagAt(t, "case/malformed", "%s asks for %d words, more than the configured limit of %d; it is removed", t.Text, n, rules.MaxRange)}, true
+	case n == 0:
+		return []diag.Diagnostic{diagAt(t, "case/no-op", "%s changes nothing", t.Text)}, true
+	}
+
+	if got := len(collectPreviousWordIdxsSameLine(toks, i, n)); got == 0 {
+		return []diag.Diagnostic{diagAt(t, "case/dangling", "%s has no word before it", t.Text)}, true
+	} else if got < n {
+		return []diag.Diagnostic{diagAt(t, "case/dangling", "%s asks for %d words but only %d come before it", t.Text, n, got)}, true
+	}
+	return nil, true
+}
@@ -1,92 +1,502 @@
 package transform
 
 import (
+	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 
+	"go-reloaded/internal/diag"
 	"go-reloaded/internal/token"
 )
 
-func isValidHex(s string) bool {
-	if s == "" {
-		return false
+// Number conversion tags. (hex), (bin) and (oct) are aliases for
+// (base, 16), (base, 2) and (base, 8); (base, N) accepts any N in 2..36.
+// Each converts the previous Word from base N to decimal and is then dropped.
+// A count converts the last n Words on the line instead, parsed like the n of
+// case tags: (hex, 3), (bin, 2), (base, 36, 4); (hex) is (hex, 1). A Word that
+// is not a valid base-N number is left unchanged.
+
+type numKind int
+
+const (
+	numUnknown   numKind = iota // not a number tag
+	numMalformed                // (base, x) with a bad or out-of-range base, or a bad count
+	numOK                       // valid number tag
+)
+
+// baseAliases maps tag names to their base.
+var baseAliases = map[string]int{"hex": 16, "bin": 2, "oct": 8}
+
+// parseNumberTag parses "(hex)", "(bin, 3)", "(oct)", "(base, N)" and "(base, N, n)".
+// name is the tag name ("hex", "bin", "oct" or "base"), which is also the
+// pipeline stage that handles it; n is the word count (default 1).
+func parseNumberTag(s string) (name string, base, n int, kind numKind) {
+	s = strings.TrimSpace(s)
+	if len(s) < 3 || s[0] != '(' || s[len(s)-1] != ')' {
+		return "", 0, 0, numUnknown
+	}
+	parts := strings.Split(s[1:len(s)-1], ",")
+	name = strings.ToLower(strings.TrimSpace(parts[0]))
+
+	args := parts[1:]
+	if b, ok := baseAliases[name]; ok {
+		base = b
+	} else if name == "base" {
+		if len(args) == 0 {
+			return name, 0, 0, numMalformed
+		}
+		b, err := strconv.Atoi(strings.TrimSpace(args[0]))
+		if err != nil || b < 2 || b > 36 {
+			return name, 0, 0, numMalformed
+		}
+		base, args = b, args[1:]
+	} else {
+		return "", 0, 0, numUnknown
 	}
-	for _, r := range strings.ToLower(s) {
-		if !((r >= '0' && r <= '9') || (r >= 'a' && r <= 'f')) {
-			return false
+
+	n = 1
+	switch len(args) {
+	case 0:
+	case 1:
+		var ok bool
+		if n, ok = parseTagCount(args[0]); !ok {
+			return name, 0, 0, numMalformed
 		}
+	default:
+		return name, 0, 0, numMalformed
 	}
-	return true
+	return name, base, n, numOK
 }
 
-func isValidBin(s string) bool {
-	if s == "" {
-		return false
+// previousWordsOnLine returns the indexes of the last n Word tokens before
+// toks[i], left to right, not looking past a newline or a protected span.
+func previousWordsOnLine(toks []token.Tok, i, n int) []int {
+	var idxs []int
+	for j := i - 1; j >= 0 && len(idxs) < n; j-- {
+		if toks[j].K == token.Space && hasNewline(toks[j].Text) || isBarrier(toks[j]) {
+			break
+		}
+		if toks[j].K == token.Word {
+			idxs = append(idxs, j)
+		}
+	}
+	for l, r := 0, len(idxs)-1; l < r; l, r = l+1, r-1 {
+		idxs[l], idxs[r] = idxs[r], idxs[l]
+	}
+	return idxs
+}
+
+// digitValue returns the value of r as a base-36 digit (0-9 then a-z,
+// case-insensitive), or 36 if it is not one.
+func digitValue(r rune) int {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r - '0')
+	case r >= 'a' && r <= 'z':
+		return int(r-'a') + 10
+	case r >= 'A' && r <= 'Z':
+		return int(r-'A') + 10
 	}
+	return 36
+}
+
+// invalidDigit returns the first rune of s that is not a base-N digit.
+func invalidDigit(s string, base int) (rune, bool) {
 	for _, r := range s {
-		if r != '0' && r != '1' {
-			return false
+		if digitValue(r) >= base {
+			return r, true
 		}
 	}
-	return true
+	return 0, false
 }
 
-func ApplyHex(toks []token.Tok) []token.Tok {
-	// First pass: modify tokens in place
-	for i, t := range toks {
-		if t.K == token.Tag && t.Text == "(hex)" {
-			// Find previous word and convert
-			for j := i - 1; j >= 0; j-- {
-				if toks[j].K == token.Word {
-					if isValidHex(toks[j].Text) {
-						if val, err := strconv.ParseInt(toks[j].Text, 16, 64); err == nil {
-							toks[j].Text = strconv.FormatInt(val, 10)
-						}
-					}
-					break
-				}
-			}
+// isValidInBase reports whether s is a non-empty string of base-N digits.
+func isValidInBase(s string, base int) bool {
+	_, bad := invalidDigit(s, base)
+	return s != "" && !bad
+}
+
+// basePrefixes are the literal prefixes accepted for a base ("0x1F", "#ff").
+var basePrefixes = map[int][]string{
+	16: {"0x", "#"},
+	2:  {"0b"},
+	8:  {"0o"},
+}
+
+// parseLiteral splits a numeric literal into its sign and plain base-N digits.
+// It accepts a leading + or -, a prefix matching the base (0x or # for 16,
+// 0b for 2, 0o for 8) and single underscores between digits ("1010_0101").
+// why explains a failure.
+func parseLiteral(s string, base int) (neg bool, digits string, why string) {
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg, s = s[0] == '-', s[1:]
+	}
+	for _, p := range basePrefixes[base] {
+		if len(s) > len(p) && strings.EqualFold(s[:len(p)], p) {
+			s = s[len(p):]
+			break
 		}
 	}
+	if s == "" {
+		return false, "", "no digits"
+	}
+	if s[0] == '_' || s[len(s)-1] == '_' || strings.Contains(s, "__") {
+		return false, "", "misplaced '_'"
+	}
+	s = strings.ReplaceAll(s, "_", "")
+	if r, bad := invalidDigit(s, base); bad {
+		return false, "", fmt.Sprintf("%q is not a base-%d digit", r, base)
+	}
+	return neg, s, ""
+}
+
+// convertBase converts the literal s (see parseLiteral) from base to decimal,
+// at any length (math/big) and keeping its sign; ok is false if s is not a
+// valid base-N literal.
+func convertBase(s string, base int) (string, bool) {
+	neg, digits, why := parseLiteral(s, base)
+	if why != "" {
+		return "", false
+	}
+	val, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return "", false
+	}
+	if neg {
+		val.Neg(val)
+	}
+	return val.String(), true
+}
 
-	// Second pass: filter out hex tags
-	out := []token.Tok{}
+// applyNumberTags converts the Words before every valid number tag called name
+// and drops those tags (n == 0 tags too). Malformed tags are kept for ApplyDropTags.
+func applyNumberTags(toks []token.Tok, name string) []token.Tok {
+	out := make([]token.Tok, 0, len(toks))
 	for _, t := range toks {
-		if t.K == token.Tag && t.Text == "(hex)" {
+		if t.K != token.Tag {
+			out = append(out, t)
+			continue
+		}
+		tagName, base, n, kind := parseNumberTag(t.Text)
+		if tagName != name || kind != numOK {
+			out = append(out, t)
 			continue
 		}
-		out = append(out, t)
+		for _, j := range previousWordsOnLine(out, len(out), n) {
+			if dec, ok := convertBase(out[j].Text, base); ok {
+				out[j].Text = dec
+			}
+		}
 	}
-
 	return out
 }
 
-func ApplyBin(toks []token.Tok) []token.Tok {
-	// First pass: modify tokens in place
-	for i, t := range toks {
-		if t.K == token.Tag && t.Text == "(bin)" {
-			// Find previous word and convert
-			for j := i - 1; j >= 0; j-- {
-				if toks[j].K == token.Word {
-					if isValidBin(toks[j].Text) {
-						if val, err := strconv.ParseInt(toks[j].Text, 2, 64); err == nil {
-							toks[j].Text = strconv.FormatInt(val, 10)
-						}
-					}
-					break
-				}
+func ApplyHex(toks []token.Tok) []token.Tok { return applyNumberTags(toks, "hex") }
+
+func ApplyBin(toks []token.Tok) []token.Tok { return applyNumberTags(toks, "bin") }
+
+// ApplyOct converts the Word before (oct) from octal to decimal.
+func ApplyOct(toks []token.Tok) []token.Tok { return applyNumberTags(toks, "oct") }
+
+// ApplyBase converts the Word before (base, N) from base N to decimal.
+func ApplyBase(toks []token.Tok) []token.Tok { return applyNumberTags(toks, "base") }
+
+// Reverse conversion tags: (to-hex), (to-bin) and (to-oct) turn the previous
+// decimal Word into base 16, 2 or 8. Optional arguments, in any order:
+//
+//	prefix (or the literal 0x / 0b / 0o)  add the base prefix
+//	upper                                  upper-case digits (0xFF)
+//	N                                      zero-pad the digits to width N
+//
+//	255 (to-hex)            -> ff
+//	255 (to-hex, 0x, upper) -> 0xFF
+//	5 (to-bin, 8)           -> 00000101
+
+// toBaseFormat is how a (to-*) tag renders its number.
+type toBaseFormat struct {
+	base   int
+	prefix string // "" for none
+	upper  bool
+	width  int
+}
+
+var toBaseTags = map[string]struct {
+	base   int
+	prefix string
+}{
+	"to-hex": {16, "0x"},
+	"to-bin": {2, "0b"},
+	"to-oct": {8, "0o"},
+}
+
+// parseToBaseTag parses "(to-hex)", "(to-bin, 0b, 8)" etc.
+func parseToBaseTag(s string) (name string, f toBaseFormat, kind numKind) {
+	s = strings.TrimSpace(s)
+	if len(s) < 3 || s[0] != '(' || s[len(s)-1] != ')' {
+		return "", f, numUnknown
+	}
+	parts := strings.Split(s[1:len(s)-1], ",")
+	name = strings.ToLower(strings.TrimSpace(parts[0]))
+	tag, ok := toBaseTags[name]
+	if !ok {
+		return "", f, numUnknown
+	}
+	f.base = tag.base
+
+	for _, p := range parts[1:] {
+		arg := strings.ToLower(strings.TrimSpace(p))
+		switch {
+		case arg == "prefix" || arg == tag.prefix:
+			f.prefix = tag.prefix
+		case arg == "upper":
+			f.upper = true
+		case arg != "" && isValidInBase(arg, 10):
+			w, err := strconv.Atoi(arg)
+			if err != nil || w > 256 {
+				return name, f, numMalformed
 			}
+			f.width = w
+		default:
+			return name, f, numMalformed
 		}
 	}
+	return name, f, numOK
+}
+
+// formatBase renders the decimal literal s in f, at any length (math/big) and
+// keeping its sign; ok is false if s is not a decimal integer.
+func formatBase(s string, f toBaseFormat) (string, bool) {
+	neg, digits, why := parseLiteral(s, 10)
+	if why != "" {
+		return "", false
+	}
+	val, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return "", false
+	}
+
+	out := val.Text(f.base)
+	if f.upper {
+		out = strings.ToUpper(out)
+	}
+	if len(out) < f.width {
+		out = strings.Repeat("0", f.width-len(out)) + out
+	}
+	out = f.prefix + out
+	if neg && val.Sign() != 0 {
+		out = "-" + out
+	}
+	return out, true
+}
 
-	// Second pass: filter out bin tags
-	out := []token.Tok{}
+// applyToBaseTags converts the Word before every valid (to-*) tag called name,
+// on the same line, and drops those tags. Malformed tags are kept for ApplyDropTags.
+func applyToBaseTags(toks []token.Tok, name string) []token.Tok {
+	out := make([]token.Tok, 0, len(toks))
 	for _, t := range toks {
-		if t.K == token.Tag && t.Text == "(bin)" {
+		if t.K != token.Tag {
+			out = append(out, t)
 			continue
 		}
-		out = append(out, t)
+		tagName, f, kind := parseToBaseTag(t.Text)
+		if tagName != name || kind != numOK {
+			out = append(out, t)
+			continue
+		}
+		if j := previousWordOnLine(out, len(out)); j >= 0 {
+			if s, ok := formatBase(out[j].Text, f); ok {
+				out[j].Text = s
+			}
+		}
+	}
+	return out
+}
+
+// ApplyToHex converts the decimal Word before (to-hex) to hexadecimal.
+func ApplyToHex(toks []token.Tok) []token.Tok { return applyToBaseTags(toks, "to-hex") }
+
+// ApplyToBin converts the decimal Word before (to-bin) to binary.
+func ApplyToBin(toks []token.Tok) []token.Tok { return applyToBaseTags(toks, "to-bin") }
+
+// ApplyToOct converts the decimal Word before (to-oct) to octal.
+func ApplyToOct(toks []token.Tok) []token.Tok { return applyToBaseTags(toks, "to-oct") }
+
+// lintNumberTag checks a number tag at toks[i]; ok is false for other tags.
+func lintNumberTag(toks []token.Tok, i int) (ds []diag.Diagnostic, ok bool) {
+	t := toks[i]
+	name, base, n, kind := parseNumberTag(t.Text)
+	switch kind {
+	case numUnknown:
+		return lintToBaseTag(toks, i)
+	case numMalformed:
+		return []diag.Diagnostic{diagAt(t, name+"/malformed", "%s is not a valid number tag (want (hex), (bin), (oct) or (base, N) with N in 2..36, each with an optional count); it is removed", t.Text)}, true
+	}
+	if n == 0 {
+		return []diag.Diagnostic{diagAt(t, name+"/no-op", "%s changes nothing", t.Text)}, true
+	}
+
+	idxs := previousWordsOnLine(toks, i, n)
+	switch {
+	case len(idxs) == 0:
+		return []diag.Diagnostic{diagAt(t, name+"/dangling", "%s has no word before it on its line", t.Text)}, true
+	case len(idxs) < n:
+		ds = append(ds, diagAt(t, name+"/dangling", "%s asks for %d words but finds %d on its line", t.Text, n, len(idxs)))
+	}
+	for _, j := range idxs {
+		w := toks[j]
+		if _, ok := convertBase(w.Text, base); !ok {
+			ds = append(ds, diagAt(w, name+"/invalid", "%q is not a base-%d number (%s); %s leaves it unchanged", w.Text, base, whyInvalid(w.Text, base), t.Text))
+		}
 	}
+	return ds, true
+}
+
+// lintToBaseTag checks a (to-*) tag at toks[i]; ok is false for other tags.
+func lintToBaseTag(toks []token.Tok, i int) (ds []diag.Diagnostic, ok bool) {
+	t := toks[i]
+	name, f, kind := parseToBaseTag(t.Text)
+	switch kind {
+	case numUnknown:
+		return lintConverterTag(toks, i)
+	case numMalformed:
+		return []diag.Diagnostic{diagAt(t, name+"/malformed", "%s has an unknown argument (want prefix, upper or a width); it is removed", t.Text)}, true
+	}
+
+	j := previousWordOnLine(toks, i)
+	if j < 0 {
+		return []diag.Diagnostic{diagAt(t, name+"/dangling", "%s has no word before it on its line", t.Text)}, true
+	}
+	w := toks[j]
+	if _, ok := formatBase(w.Text, f); !ok {
+		return []diag.Diagnostic{diagAt(w, name+"/invalid", "%q is not a decimal integer (%s); %s is removed and the word kept", w.Text, whyInvalid(w.Text, 10), t.Text)}, true
+	}
+	return nil, true
+}
+
+// Converter tags rewrite the previous Word on its line with a converter:
+// (words), (roman), (from-roman) and (fmt, ...). (num) is parsed here too but
+// reads several Words (see ApplyNum).
+
+// converter converts a Word's text, or explains why it cannot.
+type converter func(s string) (out, why string)
+
+// converterTag builds the converter for a tag's arguments: the raw text after
+// the tag name, including its leading comma ("" when there are none).
+type converterTag struct {
+	parse func(args string) (converter, bool)
+	usage string // completes "(tag) ..." in malformed-tag messages
+}
+
+// noArgs is a converterTag that takes no arguments.
+func noArgs(c converter) converterTag {
+	return converterTag{
+		parse: func(args string) (converter, bool) { return c, args == "" },
+		usage: "takes no arguments",
+	}
+}
+
+var converterTags = map[string]converterTag{
+	"words":      noArgs(numberToWords),
+	"num":        noArgs(nil),
+	"roman":      noArgs(toRoman),
+	"from-roman": noArgs(fromRoman),
+	"fmt":        {parse: parseFmtArgs, usage: "has a bad argument (want a locale such as en or de, a group separator and/or a number of decimals)"},
+}
 
+// parseConverterTag parses converter tags such as "(words)" or "(fmt, de, 2)".
+// conv is nil for (num).
+func parseConverterTag(s string) (name string, conv converter, kind numKind) {
+	s = strings.TrimSpace(s)
+	if len(s) < 3 || s[0] != '(' || s[len(s)-1] != ')' {
+		return "", nil, numUnknown
+	}
+	body := s[1 : len(s)-1]
+	args := ""
+	if i := strings.IndexByte(body, ','); i >= 0 {
+		body, args = body[:i], body[i:]
+	}
+	name = strings.ToLower(strings.TrimSpace(body))
+	tag, ok := converterTags[name]
+	if !ok {
+		return "", nil, numUnknown
+	}
+	if conv, ok = tag.parse(args); !ok {
+		return name, nil, numMalformed
+	}
+	return name, conv, numOK
+}
+
+// applyConverterTags converts the Word before every valid converter tag called
+// name and drops those tags. Words the converter rejects are left unchanged.
+func applyConverterTags(toks []token.Tok, name string) []token.Tok {
+	out := make([]token.Tok, 0, len(toks))
+	for _, t := range toks {
+		if t.K != token.Tag {
+			out = append(out, t)
+			continue
+		}
+		tagName, convert, kind := parseConverterTag(t.Text)
+		if tagName != name || kind != numOK {
+			out = append(out, t)
+			continue
+		}
+		if j := previousWordOnLine(out, len(out)); j >= 0 {
+			if s, why := convert(out[j].Text); why == "" {
+				out[j].Text = s
+			}
+		}
+	}
 	return out
 }
+
+// lintConverterTag checks a converter tag at toks[i]; ok is false for other tags.
+func lintConverterTag(toks []token.Tok, i int) (ds []diag.Diagnostic, ok bool) {
+	t := toks[i]
+	name, convert, kind := parseConverterTag(t.Text)
+	switch kind {
+	case numUnknown:
+		return nil, false
+	case numMalformed:
+		return []diag.Diagnostic{diagAt(t, name+"/malformed", "%s %s; it is removed", t.Text, converterTags[name].usage)}, true
+	}
+
+	j := previousWordOnLine(toks, i)
+	if j < 0 {
+		return []diag.Diagnostic{diagAt(t, name+"/dangling", "%s has no word before it on its line", t.Text)}, true
+	}
+	w := toks[j]
+	if name == "num" {
+		if _, _, ok := numberWordRun(toks, j); !ok {
+			return []diag.Diagnostic{diagAt(w, "num/invalid", "%q does not end a spelled-out number; %s leaves it unchanged", w.Text, t.Text)}, true
+		}
+		return nil, true
+	}
+	if _, why := convert(w.Text); why != "" {
+		return []diag.Diagnostic{diagAt(w, name+"/invalid", "%q cannot be converted (%s); %s leaves it unchanged", w.Text, why, t.Text)}, true
+	}
+	return nil, true
+}
+
+// whyInvalid explains why s is not a base-N literal.
+func whyInvalid(s string, base int) string {
+	_, _, why := parseLiteral(s, base)
+	return why
+}
+
+// LintNumbers reports the number tags in toks that will not convert their
+// word, and why. It is the number-conversion subset of Lint.
+func LintNumbers(toks []token.Tok) []diag.Diagnostic {
+	toks = ValidateTags(append([]token.Tok(nil), toks...))
+	var ds []diag.Diagnostic
+	for i, t := range toks {
+		if t.K != token.Tag {
+			continue
+		}
+		if d, ok := lintNumberTag(toks, i); ok {
+			ds = append(ds, d...)
+		}
+	}
+	diag.Sort(ds)
+	return ds
+}
@@ -1,15 +1,27 @@
 package transform
 
-import "go-reloaded/internal/token"
+import (
+	"strings"
 
-// ApplyDropTags removes any remaining Tag tokens (unknown/malformed).
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/token"
+)
+
+// ApplyDropTags removes any remaining Tag tokens (unknown/malformed). A tag
+// holding a URL, email address or path is text, not a tag, and is kept.
 func ApplyDropTags(toks []token.Tok) []token.Tok {
 	out := make([]token.Tok, 0, len(toks))
 	for _, t := range toks {
 		if t.K == token.Tag {
 			if t.Text == "()" {
 				// keep literal ()
-				out = append(out, token.Tok{K: token.Word, Text: "()"})
+				t.K = token.Word
+				out = append(out, t)
+				continue
+			}
+			if token.ContainsProtected(t.Text) {
+				t.K = token.Protected
+				out = append(out, t)
 				continue
 			}
 			// otherwise drop it
@@ -19,3 +31,58 @@ func ApplyDropTags(toks []token.Tok) []token.Tok {
 	}
 	return out
 }
+
+// knownTags are suggested when an unknown tag is a likely typo of one of them.
+var knownTags = []string{"up", "low", "cap", "hex", "bin", "oct", "base", "to-hex", "to-bin", "to-oct", "words", "num", "roman", "from-roman", "fmt"}
+
+// lintUnknownTag reports a tag ApplyDropTags would delete.
+func lintUnknownTag(t token.Tok) diag.Diagnostic {
+	name := strings.ToLower(strings.TrimSpace(strings.Trim(t.Text, "()")))
+	if i := strings.IndexByte(name, ','); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	for _, k := range knownTags {
+		if editDistance(name, k) <= 1 || (len(name) == len(k) && sameLetters(name, k)) {
+			return diagAt(t, "drop-tags/unknown", "unknown tag %s is removed; did you mean (%s)?", t.Text, k)
+		}
+	}
+	return diagAt(t, "drop-tags/unknown", "unknown tag %s is removed", t.Text)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// sameLetters reports whether a and b are anagrams (catches "cpa" for "cap").
+func sameLetters(a, b string) bool {
+	count := map[rune]int{}
+	for _, r := range a {
+		count[r]++
+	}
+	for _, r := range b {
+		count[r]--
+	}
+	for _, c := range count {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -21,7 +21,8 @@ func ApplyFinalSpacingFix(toks []token.Tok) []token.Tok {
 			if j < len(toks) {
 				// Found matching quote pair - ensure proper spacing
 				// Add space before opening quote if needed
-				if len(out) > 0 && (out[len(out)-1].K == token.Word || out[len(out)-1].K == token.Punct) {
+				// (but keep —'quote' tight, see ApplyDashQuoteTight)
+				if len(out) > 0 && (out[len(out)-1].K == token.Word || out[len(out)-1].K == token.Punct) && out[len(out)-1].Text != "—" {
 					out = append(out, token.Tok{K: token.Space, Text: " "})
 				}
 				
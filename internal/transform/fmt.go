@@ -0,0 +1,178 @@
+package transform
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"go-reloaded/internal/token"
+)
+
+// Number formatting. (fmt) regroups the previous decimal number, which may
+// already be grouped in any locale ("1,000", "1.000,5") or have a fraction
+// ("3.14159"). Arguments, in any order:
+//
+//	a locale   en, de, fr or ch: its group and decimal separators
+//	a mark     one of , . ' _ as the group separator
+//	N          round (or pad) to N decimals
+//
+//	1234567 (fmt)       -> 1,234,567
+//	1234.5 (fmt, de, 2) -> 1.234,50
+//	3.14159 (fmt, 2)    -> 3.14
+//	1000000 (fmt, _)    -> 1_000_000
+
+// numberFormat is how (fmt) renders a number.
+type numberFormat struct {
+	group    string
+	decimal  string
+	decimals int // -1 keeps the fraction as written
+}
+
+// fmtLocales are the group and decimal separators of each locale.
+// French groups with a narrow no-break space.
+var fmtLocales = map[string]numberFormat{
+	"en": {group: ",", decimal: "."},
+	"de": {group: ".", decimal: ","},
+	"fr": {group: " ", decimal: ","},
+	"ch": {group: "'", decimal: "."},
+}
+
+// parseFmtArgs parses the arguments of a (fmt) tag. A bare comma is an
+// argument too, so "(fmt, ,)" groups with commas.
+func parseFmtArgs(args string) (converter, bool) {
+	f := fmtLocales["en"]
+	f.decimals = -1
+
+	var fields []string
+	for _, field := range strings.Fields(strings.TrimPrefix(args, ",")) {
+		if strings.Trim(field, ",") == "" {
+			fields = append(fields, ",")
+			continue
+		}
+		for _, arg := range strings.Split(field, ",") {
+			if arg != "" {
+				fields = append(fields, arg)
+			}
+		}
+	}
+
+	for _, arg := range fields {
+		if loc, ok := fmtLocales[strings.ToLower(arg)]; ok {
+			f.group, f.decimal = loc.group, loc.decimal
+			continue
+		}
+		switch arg {
+		case ",", "'", "_":
+			f.group, f.decimal = arg, "."
+			continue
+		case ".":
+			f.group, f.decimal = arg, ","
+			continue
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 || n > 20 {
+			return nil, false
+		}
+		f.decimals = n
+	}
+	return func(s string) (string, string) { return formatNumber(s, f) }, true
+}
+
+// splitDecimal splits a decimal number such as "-1,234.50" into its sign,
+// integer digits and fraction digits. It reads every grouping the tokenizer
+// keeps as one Word, so (fmt) reads back what it writes: groups of three
+// digits after one repeated mark (, . ' _ or a narrow no-break space) and a
+// decimal point or comma that is not the group mark ("1.234,50",
+// "1 234 567,5", "1'234'567.25"). A single dot group is a decimal point
+// ("0.995"), a single comma group is not ("1,000"). why explains a failure.
+func splitDecimal(s string) (neg bool, intPart, frac string, why string) {
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg, s = s[0] == '-', s[1:]
+	}
+	r := []rune(s)
+	digits := func(i int) int {
+		for i < len(r) && r[i] >= '0' && r[i] <= '9' {
+			i++
+		}
+		return i
+	}
+
+	j := digits(0)
+	if j == 0 {
+		return false, "", "", "not a decimal number"
+	}
+	var sb strings.Builder
+	sb.WriteString(string(r[:j]))
+	var group rune
+	groups := 0
+	if j <= 3 {
+		for j < len(r) && strings.ContainsRune(",.'_\u202f", r[j]) && (group == 0 || r[j] == group) && digits(j+1) == j+4 {
+			sb.WriteString(string(r[j+1 : j+4]))
+			group, j, groups = r[j], j+4, groups+1
+		}
+	}
+	if group == '.' && groups == 1 && j == len(r) {
+		// "1.234": a decimal point
+		return neg, string(r[:j-4]), string(r[j-3:]), ""
+	}
+	intPart = sb.String()
+
+	if j < len(r) {
+		if r[j] != '.' && r[j] != ',' || r[j] == group {
+			return false, "", "", "not a decimal number; group marks must come every three digits"
+		}
+		frac = string(r[j+1:])
+		if frac == "" || !isValidInBase(frac, 10) {
+			return false, "", "", "the part after the decimal separator is not all digits"
+		}
+	}
+	return neg, intPart, frac, ""
+}
+
+// formatNumber renders the decimal number s in f; why explains a failure.
+func formatNumber(s string, f numberFormat) (string, string) {
+	neg, intPart, frac, why := splitDecimal(s)
+	if why != "" {
+		return "", why
+	}
+
+	if f.decimals >= 0 {
+		if len(frac) > f.decimals {
+			// round half up on the decimal digits
+			val, _ := new(big.Int).SetString(intPart+frac[:f.decimals], 10)
+			if frac[f.decimals] >= '5' {
+				val.Add(val, big.NewInt(1))
+			}
+			digits := val.String()
+			if len(digits) <= f.decimals {
+				digits = strings.Repeat("0", f.decimals-len(digits)+1) + digits
+			}
+			intPart, frac = digits[:len(digits)-f.decimals], digits[len(digits)-f.decimals:]
+		} else {
+			frac += strings.Repeat("0", f.decimals-len(frac))
+		}
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	var sb strings.Builder
+	if neg && strings.Trim(intPart+frac, "0") != "" {
+		sb.WriteByte('-')
+	}
+	for i, d := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(f.group)
+		}
+		sb.WriteRune(d)
+	}
+	if frac != "" {
+		sb.WriteString(f.decimal)
+		sb.WriteString(frac)
+	}
+	return sb.String(), ""
+}
+
+// ApplyFmt regroups the number before each (fmt) tag and drops the tag.
+func ApplyFmt(toks []token.Tok) []token.Tok { return applyConverterTags(toks, "fmt") }
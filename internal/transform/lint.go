@@ -0,0 +1,91 @@
+package transform
+
+import (
+	"fmt"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/token"
+)
+
+// LintRules are the diagnostics Lint can report, named after the stage that
+// would otherwise resolve the problem silently.
+var LintRules = []diag.Rule{
+	{ID: "validate-tags/no-space", Description: "A tag glued to the previous word is not a tag; it is kept as literal text."},
+	{ID: "drop-tags/unknown", Description: "A tag no transform understands is removed from the output."},
+	{ID: "case/malformed", Description: "A case tag with a bad or out-of-range count is ignored and removed."},
+	{ID: "case/dangling", Description: "A case tag has fewer words before it than it asks for."},
+	{ID: "case/no-op", Description: "A case tag with a count of 0 changes nothing."},
+	{ID: "hex/malformed", Description: "A (hex) tag with a bad count is ignored and removed."},
+	{ID: "hex/no-op", Description: "A (hex, 0) tag changes nothing."},
+	{ID: "hex/dangling", Description: "A (hex) tag has no word before it on its line, or fewer words than its count asks for."},
+	{ID: "hex/invalid", Description: "The word before (hex) is not a hexadecimal number that can be converted."},
+	{ID: "bin/malformed", Description: "A (bin) tag with a bad count is ignored and removed."},
+	{ID: "bin/no-op", Description: "A (bin, 0) tag changes nothing."},
+	{ID: "bin/dangling", Description: "A (bin) tag has no word before it on its line, or fewer words than its count asks for."},
+	{ID: "bin/invalid", Description: "The word before (bin) is not a binary number that can be converted."},
+	{ID: "oct/malformed", Description: "An (oct) tag with a bad count is ignored and removed."},
+	{ID: "oct/no-op", Description: "An (oct, 0) tag changes nothing."},
+	{ID: "oct/dangling", Description: "An (oct) tag has no word before it on its line, or fewer words than its count asks for."},
+	{ID: "oct/invalid", Description: "The word before (oct) is not an octal number that can be converted."},
+	{ID: "base/malformed", Description: "A (base, N) tag without a base in 2..36, or with a bad count, is ignored and removed."},
+	{ID: "base/no-op", Description: "A (base, N, 0) tag changes nothing."},
+	{ID: "base/dangling", Description: "A (base, N) tag has no word before it on its line, or fewer words than its count asks for."},
+	{ID: "base/invalid", Description: "The word before (base, N) is not a base-N number that can be converted."},
+	{ID: "to-hex/malformed", Description: "A (to-hex) tag with an unknown argument is ignored and removed."},
+	{ID: "to-hex/dangling", Description: "A (to-hex) tag has no word before it on its line."},
+	{ID: "to-hex/invalid", Description: "The word before (to-hex) is not a decimal number that can be converted."},
+	{ID: "to-bin/malformed", Description: "A (to-bin) tag with an unknown argument is ignored and removed."},
+	{ID: "to-bin/dangling", Description: "A (to-bin) tag has no word before it on its line."},
+	{ID: "to-bin/invalid", Description: "The word before (to-bin) is not a decimal number that can be converted."},
+	{ID: "to-oct/malformed", Description: "A (to-oct) tag with an unknown argument is ignored and removed."},
+	{ID: "to-oct/dangling", Description: "A (to-oct) tag has no word before it on its line."},
+	{ID: "to-oct/invalid", Description: "The word before (to-oct) is not a decimal number that can be converted."},
+	{ID: "words/malformed", Description: "A (words) tag with arguments is ignored and removed."},
+	{ID: "words/dangling", Description: "A (words) tag has no word before it on its line."},
+	{ID: "words/invalid", Description: "The word before (words) is not a decimal integer that can be spelled out."},
+	{ID: "num/malformed", Description: "A (num) tag with arguments is ignored and removed."},
+	{ID: "num/dangling", Description: "A (num) tag has no word before it on its line."},
+	{ID: "num/invalid", Description: "The words before (num) do not read as a spelled-out number."},
+	{ID: "roman/malformed", Description: "A (roman) tag with arguments is ignored and removed."},
+	{ID: "roman/dangling", Description: "A (roman) tag has no word before it on its line."},
+	{ID: "roman/invalid", Description: "The word before (roman) is not a decimal integer from 1 to 3999."},
+	{ID: "from-roman/malformed", Description: "A (from-roman) tag with arguments is ignored and removed."},
+	{ID: "from-roman/dangling", Description: "A (from-roman) tag has no word before it on its line."},
+	{ID: "from-roman/invalid", Description: "The word before (from-roman) is not a well-formed Roman numeral."},
+	{ID: "fmt/malformed", Description: "A (fmt) tag with an unknown argument is ignored and removed."},
+	{ID: "fmt/dangling", Description: "A (fmt) tag has no word before it on its line."},
+	{ID: "fmt/invalid", Description: "The word before (fmt) is not a decimal number that can be formatted."},
+	{ID: "quote-pairs/unmatched", Description: "A quote has no closing partner, so its spacing is left alone."},
+}
+
+// Lint reports tags and quotes that the pipeline would silently drop, ignore or
+// leave alone. It does not modify toks. Positions come from the tokens, so toks
+// should come straight from token.Tokenize. File is left empty.
+func Lint(toks []token.Tok, caseRules CaseRules) []diag.Diagnostic {
+	ds := lintTagSpacing(toks)
+	toks = ValidateTags(append([]token.Tok(nil), toks...))
+
+	for i, t := range toks {
+		if t.K != token.Tag || t.Text == "()" || token.ContainsProtected(t.Text) {
+			continue
+		}
+		if d, ok := lintNumberTag(toks, i); ok {
+			ds = append(ds, d...)
+			continue
+		}
+		if d, ok := lintCaseTag(toks, i, caseRules); ok {
+			ds = append(ds, d...)
+			continue
+		}
+		ds = append(ds, lintUnknownTag(t))
+	}
+
+	ds = append(ds, lintQuotes(toks)...)
+	diag.Sort(ds)
+	return ds
+}
+
+// diagAt builds a diagnostic located at t.
+func diagAt(t token.Tok, rule, format string, args ...any) diag.Diagnostic {
+	return diag.Diagnostic{Line: t.Line, Col: t.Col, Rule: rule, Message: fmt.Sprintf(format, args...)}
+}
@@ -2,12 +2,29 @@ package transform
 
 import (
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"go-reloaded/internal/token"
 )
 
 func hasNewline(s string) bool { return strings.ContainsRune(s, '\n') }
 
+// isBarrier reports whether t stops a search for neighbouring words: a
+// protected span, but not the markup around a word ("**word**").
+func isBarrier(t token.Tok) bool { return t.K == token.Protected && !token.IsMarkup(t) }
+
+// PunctRules lists the Punct/Group texts that ApplyPunctuationWith attaches to
+// the previous word and follows with one space.
+type PunctRules struct {
+	Marks []string
+}
+
+// DefaultPunctRules are the marks ApplyPunctuation handles.
+var DefaultPunctRules = PunctRules{
+	Marks: []string{".", ",", "!", "?", ":", ";", "...", "!?", "?!"},
+}
+
 func isAsciiPunctMark(t token.Tok) bool {
 	if t.K != token.Punct && t.K != token.Group {
 		return false
@@ -28,12 +45,51 @@ func isAsciiPunctMark(t token.Tok) bool {
 	return false
 }
 
+// gluesToMark reports whether t may follow a punctuation mark without a space:
+// a placeholder, or a Symbol starting with a closing bracket or quote.
+func gluesToMark(t token.Tok) bool {
+	if t.Placeholder() {
+		return true
+	}
+	if t.K != token.Symbol {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(t.Text)
+	return unicode.In(r, unicode.Pe, unicode.Pf)
+}
+
+// isEmoticon reports whether the mark at toks[i] opens an emoticon such as
+// ":)" or ";]": a colon or semicolon directly followed by a closing bracket.
+// Its spacing is kept as written.
+func isEmoticon(toks []token.Tok, i int) bool {
+	if (toks[i].Text != ":" && toks[i].Text != ";") || i+1 >= len(toks) || toks[i+1].K != token.Symbol {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(toks[i+1].Text)
+	return unicode.Is(unicode.Pe, r)
+}
+
 func ApplyPunctuation(toks []token.Tok) []token.Tok {
+	return applyPunctuation(toks, isAsciiPunctMark)
+}
+
+// ApplyPunctuationWith is ApplyPunctuation over a custom set of marks.
+func ApplyPunctuationWith(toks []token.Tok, rules PunctRules) []token.Tok {
+	marks := make(map[string]bool, len(rules.Marks))
+	for _, m := range rules.Marks {
+		marks[m] = true
+	}
+	return applyPunctuation(toks, func(t token.Tok) bool {
+		return (t.K == token.Punct || t.K == token.Group) && marks[t.Text]
+	})
+}
+
+func applyPunctuation(toks []token.Tok, isMark func(token.Tok) bool) []token.Tok {
 	out := make([]token.Tok, 0, len(toks))
 	for i := 0; i < len(toks); i++ {
 		t := toks[i]
 
-		if isAsciiPunctMark(t) {
+		if isMark(t) && !isEmoticon(toks, i) {
 			// Remove ALL plain spaces before punct
 			for len(out) > 0 && out[len(out)-1].K == token.Space && !hasNewline(out[len(out)-1].Text) {
 				out = out[:len(out)-1]
@@ -48,6 +104,9 @@ func ApplyPunctuation(toks []token.Tok) []token.Tok {
 				}
 				if (i+1) < len(toks) && toks[i+1].K == token.Space && hasNewline(toks[i+1].Text) {
 					// newline-space follows: let it pass naturally
+				} else if (i+1) < len(toks) && gluesToMark(toks[i+1]) && toks[i].K != token.Space {
+					// glued to cut-out markup ("end.</p>") or a closing
+					// bracket ("word.)", ":)"): leave it glued
 				} else {
 					out = append(out, token.Tok{K: token.Space, Text: " "})
 				}
@@ -3,6 +3,7 @@ package transform
 import (
 	"unicode"
 
+	"go-reloaded/internal/diag"
 	"go-reloaded/internal/token"
 )
 
@@ -85,3 +86,27 @@ func trimUnicodeOuter(s string) string {
 	}
 	return string(rs[start : end+1])
 }
+
+// lintQuotes reports quotes ApplyQuotes cannot pair. A single quote glued to
+// the end of a word ("James' car") reads as an apostrophe and is not reported.
+func lintQuotes(toks []token.Tok) []diag.Diagnostic {
+	var ds []diag.Diagnostic
+	for i := 0; i < len(toks); i++ {
+		if toks[i].K != token.Quote {
+			continue
+		}
+		j := i + 1
+		for j < len(toks) && (toks[j].K != token.Quote || toks[j].Text != toks[i].Text) {
+			j++
+		}
+		if j < len(toks) {
+			i = j
+			continue
+		}
+		apostrophe := toks[i].Text == "'" && i > 0 && toks[i-1].K == token.Word
+		if !apostrophe {
+			ds = append(ds, diagAt(toks[i], "quote-pairs/unmatched", "%s has no closing %s", toks[i].Text, toks[i].Text))
+		}
+	}
+	return ds
+}
@@ -0,0 +1,87 @@
+package transform
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"go-reloaded/internal/token"
+)
+
+// Roman numerals. (roman) writes the previous decimal Word as a Roman numeral,
+// (from-roman) reads one back. Only the standard subtractive form of 1..3999 is
+// accepted, so "IIII", "IC" and "MMMM" are left unchanged (lint says why).
+//
+//	14 (roman)           -> XIV
+//	mcmxcix (from-roman) -> 1999
+
+var romanNumerals = []struct {
+	value  int
+	symbol string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"},
+	{100, "C"}, {90, "XC"}, {50, "L"}, {40, "XL"},
+	{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+var romanDigits = map[rune]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100, 'D': 500, 'M': 1000}
+
+// romanOf writes 1..3999 as a Roman numeral.
+func romanOf(n int) string {
+	var sb strings.Builder
+	for _, r := range romanNumerals {
+		for ; n >= r.value; n -= r.value {
+			sb.WriteString(r.symbol)
+		}
+	}
+	return sb.String()
+}
+
+// toRoman writes the decimal literal s as a Roman numeral; why explains a failure.
+func toRoman(s string) (string, string) {
+	neg, digits, why := parseLiteral(s, 10)
+	if why != "" {
+		return "", why
+	}
+	val, _ := new(big.Int).SetString(digits, 10)
+	if neg || val.Sign() == 0 || val.Cmp(big.NewInt(3999)) > 0 {
+		return "", "Roman numerals only go from 1 to 3999"
+	}
+	return romanOf(int(val.Int64())), ""
+}
+
+// fromRoman reads the Roman numeral s (any case) as a decimal number; why
+// explains a failure. A numeral must be written the standard way: its value is
+// summed with the subtractive rule and must spell back to the same letters.
+func fromRoman(s string) (string, string) {
+	upper := strings.ToUpper(s)
+	if upper == "" {
+		return "", "empty numeral"
+	}
+	n := 0
+	rs := []rune(upper)
+	for i, r := range rs {
+		v, ok := romanDigits[r]
+		if !ok {
+			return "", fmt.Sprintf("%q is not a Roman digit", r)
+		}
+		if i+1 < len(rs) && romanDigits[rs[i+1]] > v {
+			n -= v
+		} else {
+			n += v
+		}
+	}
+	if n < 1 || n > 3999 {
+		return "", "Roman numerals only go from 1 to 3999"
+	}
+	if want := romanOf(n); want != upper {
+		return "", fmt.Sprintf("ill-formed numeral; %d is written %s", n, want)
+	}
+	return fmt.Sprint(n), ""
+}
+
+// ApplyRoman writes the decimal Word before (roman) as a Roman numeral.
+func ApplyRoman(toks []token.Tok) []token.Tok { return applyConverterTags(toks, "roman") }
+
+// ApplyFromRoman reads the Roman numeral before (from-roman) as a decimal number.
+func ApplyFromRoman(toks []token.Tok) []token.Tok { return applyConverterTags(toks, "from-roman") }
@@ -8,12 +8,13 @@ import (
 
 // ApplySpaces collapses consecutive plain spaces to one " ".
 // It preserves any spaces containing newlines exactly.
-// If trimEnds is true, removes leading/trailing plain spaces.
+// If trimEnds is true, removes plain spaces at the start and end of the text
+// and of every line.
 func ApplySpacesWithTrim(toks []token.Tok, trimEnds bool) []token.Tok {
 	out := make([]token.Tok, 0, len(toks))
 	lastWasSpace := false
 
-	for _, t := range toks {
+	for i, t := range toks {
 		if t.K != token.Space {
 			out = append(out, t)
 			lastWasSpace = false
@@ -25,12 +26,14 @@ func ApplySpacesWithTrim(toks []token.Tok, trimEnds bool) []token.Tok {
 			lastWasSpace = false
 			continue
 		}
-		// Skip consecutive plain spaces
-		if lastWasSpace {
+		// Skip consecutive plain spaces, and spaces before a closing marker
+		// left behind by a dropped tag ("**bold (up)**" -> "**BOLD**")
+		if lastWasSpace || closesMarkup(toks, i+1) {
 			continue
 		}
-		// Add single space
-		out = append(out, token.Tok{K: token.Space, Text: " "})
+		// Add single space (keeping the source position of the first one)
+		t.Text = " "
+		out = append(out, t)
 		lastWasSpace = true
 	}
 
@@ -38,18 +41,36 @@ func ApplySpacesWithTrim(toks []token.Tok, trimEnds bool) []token.Tok {
 		return out
 	}
 
-	// Trim leading plain space
-	if out[0].K == token.Space && !strings.ContainsRune(out[0].Text, '\n') {
-		out = out[1:]
+	// Trim plain spaces at both ends of the text and of every line, such as
+	// the one left before a newline by a dropped tag ("10 (hex)\n" -> "16\n")
+	trimmed := make([]token.Tok, 0, len(out))
+	for i, t := range out {
+		if t.K == token.Space && !strings.ContainsRune(t.Text, '\n') &&
+			(i == 0 || i == len(out)-1 || isLineBreak(out[i-1]) || isLineBreak(out[i+1])) {
+			continue
+		}
+		trimmed = append(trimmed, t)
+	}
+	return trimmed
+}
+
+// isLineBreak reports whether t is whitespace containing a newline.
+func isLineBreak(t token.Tok) bool {
+	return t.K == token.Space && strings.ContainsRune(t.Text, '\n')
+}
+
+// closesMarkup reports whether toks[i] is a markup placeholder that closes a
+// span: it follows a word and is not followed by one.
+func closesMarkup(toks []token.Tok, i int) bool {
+	if i >= len(toks) || !token.IsMarkup(toks[i]) || i < 2 || toks[i-2].K == token.Space {
+		return false
 	}
-	// Trim trailing plain space
-	if len(out) > 0 {
-		last := out[len(out)-1]
-		if last.K == token.Space && !strings.ContainsRune(last.Text, '\n') {
-			out = out[:len(out)-1]
+	for j := i + 1; j < len(toks); j++ {
+		if !token.IsMarkup(toks[j]) {
+			return toks[j].K != token.Word
 		}
 	}
-	return out
+	return true
 }
 
 // Backwards compatible wrapper (no trim)
@@ -1,6 +1,9 @@
 package transform
 
-import "go-reloaded/internal/token"
+import (
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/token"
+)
 
 // ValidateTags converts malformed tags (no space before) to punctuation
 func ValidateTags(toks []token.Tok) []token.Tok {
@@ -18,3 +21,14 @@ func ValidateTags(toks []token.Tok) []token.Tok {
 	}
 	return out
 }
+
+// lintTagSpacing reports the tags ValidateTags demotes to punctuation.
+func lintTagSpacing(toks []token.Tok) []diag.Diagnostic {
+	var ds []diag.Diagnostic
+	for i, t := range toks {
+		if t.K == token.Tag && (i == 0 || toks[i-1].K != token.Space) {
+			ds = append(ds, diagAt(t, "validate-tags/no-space", "%s needs a space before it to be a tag; it is kept as text", t.Text))
+		}
+	}
+	return ds
+}
@@ -0,0 +1,296 @@
+package transform
+
+import (
+	"math/big"
+	"strings"
+
+	"go-reloaded/internal/token"
+)
+
+// Number words. (words) spells out the previous decimal Word; (num) turns the
+// number words before it back into digits:
+//
+//	42 (words)                      -> forty-two
+//	-1200 (words)                   -> minus one thousand two hundred
+//	one hundred and forty-two (num) -> 142
+//
+// (num) takes the longest run of number words before it on the line that reads
+// as one number, so "page one hundred (num)" becomes "page 100". Tens and units
+// are joined with a hyphen, as the tokenizer keeps "forty-two" as one Word.
+// Both run before the articles stage, so "a 8 (words)" becomes "an eight".
+
+var unitWords = []string{
+	"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+	"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen",
+	"seventeen", "eighteen", "nineteen",
+}
+
+var tensWords = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+
+// scaleWords are the names of successive powers of 1000, from 1000^1.
+var scaleWords = []string{
+	"thousand", "million", "billion", "trillion", "quadrillion", "quintillion",
+	"sextillion", "septillion", "octillion", "nonillion", "decillion",
+}
+
+// wordValues maps every unit and tens word to its value.
+var wordValues = func() map[string]int {
+	m := map[string]int{}
+	for v, w := range unitWords {
+		m[w] = v
+	}
+	for v, w := range tensWords {
+		if w != "" {
+			m[w] = v * 10
+		}
+	}
+	return m
+}()
+
+// scaleIndex maps "thousand" to 1, "million" to 2 and so on.
+var scaleIndex = func() map[string]int {
+	m := map[string]int{}
+	for i, w := range scaleWords {
+		m[w] = i + 1
+	}
+	return m
+}()
+
+// numberToWords spells out the decimal literal s; why explains a failure.
+func numberToWords(s string) (words string, why string) {
+	if strings.Contains(s, ",") {
+		// grouped: "1,000"
+		if neg, intPart, frac, why := splitDecimal(s); why == "" && frac == "" {
+			s = intPart
+			if neg {
+				s = "-" + s
+			}
+		}
+	}
+	neg, digits, why := parseLiteral(s, 10)
+	if why != "" {
+		return "", why
+	}
+	val, _ := new(big.Int).SetString(digits, 10)
+	if val.Sign() == 0 {
+		return "zero", ""
+	}
+
+	// split into groups of three digits, least significant first
+	var groups []int
+	thousand := big.NewInt(1000)
+	for rest, g := new(big.Int).Set(val), new(big.Int); rest.Sign() > 0; {
+		rest.DivMod(rest, thousand, g)
+		groups = append(groups, int(g.Int64()))
+	}
+	if len(groups) > len(scaleWords)+1 {
+		return "", "too large to spell out; the largest scale is " + scaleWords[len(scaleWords)-1]
+	}
+
+	var parts []string
+	if neg {
+		parts = append(parts, "minus")
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		if groups[i] == 0 {
+			continue
+		}
+		parts = append(parts, groupToWords(groups[i])...)
+		if i > 0 {
+			parts = append(parts, scaleWords[i-1])
+		}
+	}
+	return strings.Join(parts, " "), ""
+}
+
+// groupToWords spells out 1..999.
+func groupToWords(n int) []string {
+	var parts []string
+	if n >= 100 {
+		parts = append(parts, unitWords[n/100], "hundred")
+		n %= 100
+	}
+	switch {
+	case n == 0:
+	case n < 20:
+		parts = append(parts, unitWords[n])
+	case n%10 == 0:
+		parts = append(parts, tensWords[n/10])
+	default:
+		parts = append(parts, tensWords[n/10]+"-"+unitWords[n%10])
+	}
+	return parts
+}
+
+// isNumberWord reports whether w can be part of a spelled-out number.
+func isNumberWord(w string) bool {
+	w = strings.ToLower(w)
+	if _, ok := wordValues[w]; ok {
+		return true
+	}
+	if _, ok := scaleIndex[w]; ok {
+		return true
+	}
+	if tens, unit, ok := strings.Cut(w, "-"); ok {
+		t, u := wordValues[tens], wordValues[unit]
+		return t >= 20 && t%10 == 0 && u >= 1 && u <= 9
+	}
+	switch w {
+	case "hundred", "and", "minus", "negative":
+		return true
+	}
+	return false
+}
+
+// wordsToNumber reads words ("one", "hundred", "and", "forty-two") as one
+// number. It accepts an optional leading "minus" or "negative", "and" after
+// hundred or a scale word, and scales in strictly decreasing order.
+func wordsToNumber(words []string) (*big.Int, bool) {
+	ws := make([]string, len(words))
+	for i, w := range words {
+		ws[i] = strings.ToLower(w)
+	}
+	neg := false
+	if len(ws) > 0 && (ws[0] == "minus" || ws[0] == "negative") {
+		neg, ws = true, ws[1:]
+	}
+	if len(ws) == 1 && ws[0] == "zero" {
+		return new(big.Int), true
+	}
+	if len(ws) == 0 {
+		return nil, false
+	}
+
+	total := new(big.Int)
+	lastScale := len(scaleWords) + 1
+	for i := 0; i < len(ws); {
+		if i > 0 && ws[i] == "and" {
+			i++
+		}
+		g, next, ok := parseWordGroup(ws, i)
+		if !ok {
+			return nil, false
+		}
+		i = next
+		if i == len(ws) {
+			total.Add(total, big.NewInt(int64(g)))
+			break
+		}
+		s, ok := scaleIndex[ws[i]]
+		if !ok || s >= lastScale {
+			return nil, false
+		}
+		scale := new(big.Int).Exp(big.NewInt(1000), big.NewInt(int64(s)), nil)
+		total.Add(total, scale.Mul(scale, big.NewInt(int64(g))))
+		lastScale = s
+		i++
+	}
+	if neg {
+		total.Neg(total)
+	}
+	return total, true
+}
+
+// parseWordGroup reads 1..999 from ws[i:]: an optional "<unit> hundred [and]"
+// followed by an optional tens, tens-unit, teen or unit word.
+func parseWordGroup(ws []string, i int) (n, next int, ok bool) {
+	if i+1 < len(ws) && ws[i+1] == "hundred" {
+		u := wordValues[ws[i]]
+		if u < 1 || u > 9 {
+			return 0, i, false
+		}
+		n, i = u*100, i+2
+		if i+1 < len(ws) && ws[i] == "and" {
+			i++
+		}
+	}
+	if i < len(ws) {
+		w := ws[i]
+		if tens, unit, hyphen := strings.Cut(w, "-"); hyphen {
+			t, u := wordValues[tens], wordValues[unit]
+			if t >= 20 && t%10 == 0 && u >= 1 && u <= 9 {
+				n, i = n+t+u, i+1
+			}
+		} else if v, known := wordValues[w]; known && v > 0 {
+			n, i = n+v, i+1
+			if v >= 20 && i < len(ws) {
+				// "forty two" without the hyphen
+				if u := wordValues[ws[i]]; u >= 1 && u <= 9 {
+					n, i = n+u, i+1
+				}
+			}
+		}
+	}
+	return n, i, n > 0
+}
+
+// numberWordRun returns the index of the first Word of the longest run of
+// number words ending at toks[last] that reads as one number, and its value.
+// The run stays on one line and is separated by plain spaces only.
+func numberWordRun(toks []token.Tok, last int) (first int, val *big.Int, ok bool) {
+	var idxs []int
+	for j := last; j >= 0; j-- {
+		t := toks[j]
+		if t.K == token.Space && !hasNewline(t.Text) {
+			continue
+		}
+		if t.K != token.Word || !isNumberWord(t.Text) {
+			break
+		}
+		idxs = append(idxs, j)
+	}
+	// idxs runs right to left; try the longest suffix of the run first
+	for k := len(idxs); k > 0; k-- {
+		words := make([]string, k)
+		for w := 0; w < k; w++ {
+			words[w] = toks[idxs[k-1-w]].Text
+		}
+		if v, ok := wordsToNumber(words); ok {
+			return idxs[k-1], v, true
+		}
+	}
+	return 0, nil, false
+}
+
+// previousWordOnLine returns the index of the last Word before toks[i] on its
+// line, or -1.
+func previousWordOnLine(toks []token.Tok, i int) int {
+	if idxs := previousWordsOnLine(toks, i, 1); len(idxs) > 0 {
+		return idxs[0]
+	}
+	return -1
+}
+
+// ApplyWords spells out the decimal Word before each (words) tag and drops the
+// tag. A Word that is not a decimal integer is left unchanged.
+func ApplyWords(toks []token.Tok) []token.Tok { return applyConverterTags(toks, "words") }
+
+// ApplyNum replaces the number words before each (num) tag with digits and
+// drops the tag. Words that do not read as a number are left unchanged.
+func ApplyNum(toks []token.Tok) []token.Tok {
+	out := make([]token.Tok, 0, len(toks))
+	for _, t := range toks {
+		if t.K != token.Tag {
+			out = append(out, t)
+			continue
+		}
+		if name, _, kind := parseConverterTag(t.Text); name != "num" || kind != numOK {
+			out = append(out, t)
+			continue
+		}
+		last := previousWordOnLine(out, len(out))
+		if last < 0 {
+			continue
+		}
+		first, val, ok := numberWordRun(out, last)
+		if !ok {
+			continue
+		}
+		// keep the spaces between the run and the tag
+		tail := append([]token.Tok(nil), out[last+1:]...)
+		num := out[first]
+		num.Text = val.String()
+		out = append(append(out[:first], num), tail...)
+	}
+	return out
+}
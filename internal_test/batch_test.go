@@ -0,0 +1,160 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/io"
+)
+
+func TestOutputPaths(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a/x.txt", "b/x.txt", "b/sub/y.txt"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("text\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, b := filepath.Join(root, "a"), filepath.Join(root, "b")
+	out := filepath.Join(root, "out")
+
+	cases := []struct {
+		name    string
+		args    []string
+		want    []string // output paths relative to out, or nil for a collision
+		wantErr string
+	}{
+		{"one directory", []string{b}, []string{"sub/y.txt", "x.txt"}, ""},
+		{"distinct files", []string{filepath.Join(a, "x.txt"), filepath.Join(b, "sub", "y.txt")}, []string{"x.txt", "y.txt"}, ""},
+		{"same file twice", []string{filepath.Join(a, "x.txt"), filepath.Join(a, "x.txt")}, []string{"x.txt"}, ""},
+		{"files with the same name", []string{filepath.Join(a, "x.txt"), filepath.Join(b, "x.txt")}, nil, "x.txt"},
+		{"directories with the same file", []string{a, b}, nil, "x.txt"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			inputs, err := io.ExpandInputs(tc.args, ".txt")
+			if err != nil {
+				t.Fatalf("ExpandInputs: %v", err)
+			}
+			got, err := io.OutputPaths(inputs, out)
+			if tc.want == nil {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("OutputPaths = %v, %v; want an error naming %s", got, err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OutputPaths: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("OutputPaths = %v, want %v", got, tc.want)
+			}
+			for i, w := range tc.want {
+				if got[i] != filepath.Join(out, w) {
+					t.Errorf("output %d = %s, want %s", i, got[i], filepath.Join(out, w))
+				}
+			}
+		})
+	}
+}
+
+func TestWriteKeepsTarget(t *testing.T) {
+	dir := t.TempDir()
+	file, link := filepath.Join(dir, "file.txt"), filepath.Join(dir, "link.txt")
+	if err := os.WriteFile(file, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(file, link); err != nil {
+		t.Skipf("no hard links here: %v", err)
+	}
+	if err := io.WriteFile(link, "new\n"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "new\n" {
+		t.Errorf("hard link broken: %s holds %q", file, data)
+	}
+
+	// not a regular file: written through, never replaced
+	if err := io.ReplaceFile(os.DevNull, "discarded\n", ""); err != nil {
+		t.Fatalf("ReplaceFile(%s): %v", os.DevNull, err)
+	}
+	if info, err := os.Stat(os.DevNull); err != nil || info.Mode().IsRegular() {
+		t.Errorf("%s was replaced by a regular file", os.DevNull)
+	}
+}
+
+func TestExpandInputsSameFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(file, []byte("text\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{file, filepath.Join(dir, ".", "a.txt"), filepath.Join(dir, "sub", "..", "a.txt")}
+	if err := os.Symlink(file, filepath.Join(dir, "sym.txt")); err == nil {
+		args = append(args, filepath.Join(dir, "sym.txt"))
+	}
+	if err := os.Link(file, filepath.Join(dir, "hard.txt")); err == nil {
+		args = append(args, filepath.Join(dir, "hard.txt"))
+	}
+	inputs, err := io.ExpandInputs(append(args, dir), ".txt")
+	if err != nil {
+		t.Fatalf("ExpandInputs: %v", err)
+	}
+	if len(inputs) != 1 {
+		t.Errorf("ExpandInputs(%v) = %v, want the one file once", args, inputs)
+	}
+}
+
+func TestReplaceFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, []byte("old\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(file, 0640); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink(file, link); err != nil {
+		t.Skipf("no symlinks here: %v", err)
+	}
+
+	// through the symlink, with a backup
+	if err := io.ReplaceFile(link, "new\n", ".bak"); err != nil {
+		t.Fatalf("ReplaceFile: %v", err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "new\n" {
+		t.Errorf("%s holds %q, want %q", file, data, "new\n")
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("%s is no longer a symlink", link)
+	}
+	if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("mode of %s = %v, want %v", file, info.Mode().Perm(), os.FileMode(0640))
+	}
+	if data, _ := os.ReadFile(file + ".bak"); string(data) != "old\n" {
+		t.Errorf("backup holds %q, want %q", data, "old\n")
+	}
+
+	// a failed replace leaves the target and no temporary file behind
+	if err := io.ReplaceFile(file, "newer\n", string(filepath.Separator)+"missing"+string(filepath.Separator)+"x"); err == nil {
+		t.Fatal("ReplaceFile with an unwritable backup: no error")
+	}
+	if data, _ := os.ReadFile(file); string(data) != "new\n" {
+		t.Errorf("after a failed replace %s holds %q, want %q", file, data, "new\n")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/config"
+)
+
+func TestConfigLoad(t *testing.T) {
+	cases := []struct {
+		name    string
+		file    string
+		src     string
+		check   func(*config.Config) bool // nil when an error is expected
+		wantErr string
+	}{
+		{"hash inside strings", ".goreloaded.toml",
+			`disable = ["#1", 'a # b'] # a comment`,
+			func(c *config.Config) bool { return reflect.DeepEqual(c.Disable, []string{"#1", "a # b"}) }, ""},
+		{"escapes", ".goreloaded.toml",
+			`[articles]
+an = ["h\"on", "\u00e9t", 'c:\no']`,
+			func(c *config.Config) bool {
+				return reflect.DeepEqual(c.Articles.An, []string{`h"on`, "ét", `c:\no`})
+			}, ""},
+		{"multi-line array", ".goreloaded.toml",
+			`stages = [
+  "hex",   # first
+  "case",
+]
+[case]
+max_range = 1_0`,
+			func(c *config.Config) bool {
+				return reflect.DeepEqual(c.Stages, []string{"hex", "case"}) && c.Case.MaxRange == 10
+			}, ""},
+		{"table header", ".goreloaded.toml",
+			`[punctuation]
+marks = [".", "!"]`,
+			func(c *config.Config) bool { return reflect.DeepEqual(c.Punctuation.Marks, []string{".", "!"}) }, ""},
+		{"json", ".goreloaded.json",
+			`{"disable": ["quotes"], "case": {"max_range": 5}}`,
+			func(c *config.Config) bool {
+				return reflect.DeepEqual(c.Disable, []string{"quotes"}) && c.Case.MaxRange == 5
+			}, ""},
+		{"duplicate key", ".goreloaded.toml", "disable = []\ndisable = []", nil, "line 2: duplicate key"},
+		{"unknown key", ".goreloaded.toml", "disabled = []", nil, `unknown field "disabled"`},
+		{"unknown key in table", ".goreloaded.toml", "[case]\nmax = 3", nil, `unknown field "max"`},
+		{"unknown key in json", ".goreloaded.json", `{"stage": []}`, nil, `unknown field "stage"`},
+		{"string for int", ".goreloaded.toml", "[case]\nmax_range = \"ten\"", nil, "cannot unmarshal"},
+		{"int for array", ".goreloaded.toml", "stages = 3", nil, "cannot unmarshal"},
+		{"bare word", ".goreloaded.toml", "disable = [quotes]", nil, "unsupported value"},
+		{"unterminated string", ".goreloaded.toml", `disable = ["quotes]`, nil, "line 1:"},
+		{"array of tables", ".goreloaded.toml", "[[case]]", nil, "unsupported table header"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.file)
+			if err := os.WriteFile(path, []byte(tc.src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			c, err := config.Load(path)
+			if tc.check == nil {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Load = %v, want an error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if c.Path != path {
+				t.Errorf("Path = %q, want %q", c.Path, path)
+			}
+			if !tc.check(c) {
+				t.Errorf("Load(%q) = %+v", tc.src, *c)
+			}
+		})
+	}
+}
+
+func TestConfigDiscovery(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := config.ForDir("", sub); err != nil || found != "" {
+		t.Skipf("a config file above %s is in the way: %q, %v", root, found, err)
+	}
+
+	write := func(name string) string {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	rootJSON := write(".goreloaded.json")
+	rootTOML := write(".goreloaded.toml")
+	nearTOML := write(filepath.Join("a", ".goreloaded.toml"))
+	explicit := write("custom.json")
+
+	cases := []struct {
+		name     string
+		explicit string
+		dir      string
+		want     string
+	}{
+		{"flag wins over a found file", explicit, sub, explicit},
+		{"nearest file", "", sub, nearTOML},
+		{"toml before json", "", root, rootTOML},
+	}
+	for _, tc := range cases {
+		got, err := config.ForDir(tc.explicit, tc.dir)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: ForDir(%q, %q) = %q, %v; want %q", tc.name, tc.explicit, tc.dir, got, err, tc.want)
+		}
+	}
+
+	if err := os.Remove(rootTOML); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := config.ForDir("", root); got != rootJSON {
+		t.Errorf("json only: ForDir = %q, want %q", got, rootJSON)
+	}
+}
@@ -0,0 +1,180 @@
+package internal_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"go-reloaded/internal/diff"
+)
+
+// The expected hunks are what GNU diff -u prints for the same inputs.
+func TestUnified(t *testing.T) {
+	cases := []struct {
+		name, a, b, want string
+	}{
+		{"identical", "a\nb\n", "a\nb\n",
+			""},
+		{"both empty", "", "",
+			""},
+		{"empty old", "", "x\n",
+			`--- a
++++ b
+@@ -0,0 +1 @@
++x
+`},
+		{"empty new", "x\ny\n", "",
+			`--- a
++++ b
+@@ -1,2 +0,0 @@
+-x
+-y
+`},
+		{"no newline in old", "a\nb", "a\nb\n",
+			`--- a
++++ b
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`},
+		{"no newline in new", "a\nb\n", "a\nc",
+			`--- a
++++ b
+@@ -1,2 +1,2 @@
+ a
+-b
++c
+\ No newline at end of file
+`},
+		{"no newline in either", "a\nb", "a\nc",
+			`--- a
++++ b
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++c
+\ No newline at end of file
+`},
+		{"adjacent hunks merge", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n", "1\nX\n3\n4\n5\n6\n7\n8\nY\n10\n11\n12\n",
+			`--- a
++++ b
+@@ -1,12 +1,12 @@
+ 1
+-2
++X
+ 3
+ 4
+ 5
+ 6
+ 7
+ 8
+-9
++Y
+ 10
+ 11
+ 12
+`},
+		{"distant hunks split", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n", "1\nX\n3\n4\n5\n6\n7\n8\n9\nY\n11\n12\n",
+			`--- a
++++ b
+@@ -1,5 +1,5 @@
+ 1
+-2
++X
+ 3
+ 4
+ 5
+@@ -7,6 +7,6 @@
+ 7
+ 8
+ 9
+-10
++Y
+ 11
+ 12
+`},
+		{"first and last line", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "0\n2\n3\n4\n5\n6\n7\n8\n9\nZ\n",
+			`--- a
++++ b
+@@ -1,4 +1,4 @@
+-1
++0
+ 2
+ 3
+ 4
+@@ -7,4 +7,4 @@
+ 7
+ 8
+ 9
+-10
++Z
+`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := diff.Unified("a", "b", tc.a, tc.b); got != tc.want {
+				t.Errorf("Unified(%q, %q) =\n%s\nwant\n%s", tc.a, tc.b, got, tc.want)
+			}
+		})
+	}
+}
+
+// Strings must return a valid edit script of minimal length, checked against
+// the longest common subsequence.
+func TestStringsShortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	gen := func() []string {
+		s := make([]string, rng.Intn(40))
+		for i := range s {
+			s[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return s
+	}
+	for n := 0; n < 2000; n++ {
+		a, b := gen(), gen()
+		edits := diff.Strings(a, b)
+
+		x, y, changes := 0, 0, 0
+		for _, e := range edits {
+			if e.A != x || e.B != y {
+				t.Fatalf("Strings(%v, %v): edit %+v out of place at (%d, %d)", a, b, e, x, y)
+			}
+			switch e.Op {
+			case diff.OpEqual:
+				if a[x] != b[y] {
+					t.Fatalf("Strings(%v, %v): %+v keeps %q as %q", a, b, e, a[x], b[y])
+				}
+				x, y = x+1, y+1
+			case diff.OpDelete:
+				x, changes = x+1, changes+1
+			case diff.OpInsert:
+				y, changes = y+1, changes+1
+			}
+		}
+		if x != len(a) || y != len(b) {
+			t.Fatalf("Strings(%v, %v) stops at (%d, %d)", a, b, x, y)
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); changes != want {
+			t.Fatalf("Strings(%v, %v) makes %d changes, want %d", a, b, changes, want)
+		}
+	}
+}
+
+func lcs(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
@@ -0,0 +1,72 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/config"
+	"go-reloaded/internal/explain"
+	"go-reloaded/internal/pipeline"
+)
+
+// TestGoldenExplain checks the --explain reports for every
+// testdata/explain/NAME.txt against NAME.want.txt (text) and NAME.want.json,
+// using NAME.toml as the config if there is one.
+func TestGoldenExplain(t *testing.T) {
+	testdataDir := "../testdata/explain"
+
+	files, err := filepath.Glob(filepath.Join(testdataDir, "*.txt"))
+	if err != nil {
+		t.Fatalf("Failed to list testdata directory: %v", err)
+	}
+
+	for _, inputPath := range files {
+		if strings.HasSuffix(inputPath, ".want.txt") {
+			continue
+		}
+		testName := strings.TrimSuffix(filepath.Base(inputPath), ".txt")
+		t.Run(testName, func(t *testing.T) {
+			input, err := os.ReadFile(inputPath)
+			if err != nil {
+				t.Fatalf("Failed to read input file %s: %v", inputPath, err)
+			}
+
+			opts := pipeline.Options{}
+			if cfg, err := config.Load(filepath.Join(testdataDir, testName+".toml")); err == nil {
+				opts = cfg.Options()
+			} else if !os.IsNotExist(err) {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			p, err := pipeline.New(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out, report := explain.Run(p, filepath.Base(inputPath), string(input))
+			if want := p.Process(string(input)); out != want {
+				t.Errorf("Run output = %q, want %q as from Process", out, want)
+			}
+
+			for _, ext := range []string{".want.txt", ".want.json"} {
+				want, err := os.ReadFile(filepath.Join(testdataDir, testName+ext))
+				if err != nil {
+					t.Fatalf("Failed to read want file: %v", err)
+				}
+				var got strings.Builder
+				if ext == ".want.txt" {
+					err = explain.WriteText(&got, report)
+				} else {
+					err = explain.WriteJSON(&got, []explain.Report{report})
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.String() != string(want) {
+					t.Errorf("Test %s%s failed:\nGot:\n%s\nWant:\n%s", testName, ext, got.String(), want)
+				}
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 
+	"go-reloaded/internal/html"
+	"go-reloaded/internal/markdown"
 	"go-reloaded/internal/pipeline"
 )
 
@@ -44,3 +46,63 @@ func TestGolden(t *testing.T) {
 		}
 	}
 }
+
+// Processing a golden output again must leave it as it is.
+func TestGoldenStable(t *testing.T) {
+	files, err := filepath.Glob("../testdata/*.want.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range files {
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := pipeline.ProcessText(string(want)); got != string(want) {
+			t.Errorf("%s changes when processed again:\nGot:  %q\nWant: %q", path, got, want)
+		}
+	}
+}
+
+func TestGoldenMarkdown(t *testing.T) { testGoldenDocuments(t, ".md", markdown.Process) }
+
+func TestGoldenHTML(t *testing.T) { testGoldenDocuments(t, ".html", html.Process) }
+
+// testGoldenDocuments checks every testdata/NAME<ext> against NAME.want<ext>,
+// processing only the prose of the document.
+func testGoldenDocuments(t *testing.T, ext string, process func(string, func(string) string) (string, error)) {
+	testdataDir := "../testdata"
+
+	files, err := filepath.Glob(filepath.Join(testdataDir, "*"+ext))
+	if err != nil {
+		t.Fatalf("Failed to list testdata directory: %v", err)
+	}
+
+	for _, inputPath := range files {
+		if strings.HasSuffix(inputPath, ".want"+ext) {
+			continue
+		}
+		testName := strings.TrimSuffix(filepath.Base(inputPath), ext)
+		t.Run(testName, func(t *testing.T) {
+			wantPath := filepath.Join(testdataDir, testName+".want"+ext)
+
+			input, err := os.ReadFile(inputPath)
+			if err != nil {
+				t.Fatalf("Failed to read input file %s: %v", inputPath, err)
+			}
+
+			want, err := os.ReadFile(wantPath)
+			if err != nil {
+				t.Fatalf("Failed to read want file %s: %v", wantPath, err)
+			}
+
+			got, err := process(string(input), pipeline.ProcessText)
+			if err != nil {
+				t.Fatalf("Test %s failed: %v", testName, err)
+			}
+			if got != string(want) {
+				t.Errorf("Test %s failed:\nGot:  %q\nWant: %q", testName, got, string(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/config"
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/pipeline"
+)
+
+// TestGoldenLint checks the diagnostics for every testdata/lint/NAME.txt
+// against NAME.want.txt, using NAME.toml as the config if there is one.
+func TestGoldenLint(t *testing.T) {
+	testdataDir := "../testdata/lint"
+
+	files, err := filepath.Glob(filepath.Join(testdataDir, "*.txt"))
+	if err != nil {
+		t.Fatalf("Failed to list testdata directory: %v", err)
+	}
+
+	for _, inputPath := range files {
+		if strings.HasSuffix(inputPath, ".want.txt") {
+			continue
+		}
+		testName := strings.TrimSuffix(filepath.Base(inputPath), ".txt")
+		t.Run(testName, func(t *testing.T) {
+			input, err := os.ReadFile(inputPath)
+			if err != nil {
+				t.Fatalf("Failed to read input file %s: %v", inputPath, err)
+			}
+			want, err := os.ReadFile(filepath.Join(testdataDir, testName+".want.txt"))
+			if err != nil {
+				t.Fatalf("Failed to read want file: %v", err)
+			}
+
+			opts := pipeline.Options{}
+			if cfg, err := config.Load(filepath.Join(testdataDir, testName+".toml")); err == nil {
+				opts = cfg.Options()
+			} else if !os.IsNotExist(err) {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			p, err := pipeline.New(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ds := p.Lint(string(input))
+			for i := range ds {
+				ds[i].File = filepath.Base(inputPath)
+			}
+			var got strings.Builder
+			if err := diag.WriteText(&got, ds); err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != string(want) {
+				t.Errorf("Test %s failed:\nGot:\n%s\nWant:\n%s", testName, got.String(), want)
+			}
+		})
+	}
+}
+
+// TestGoldenDiagFormats checks the JSON and SARIF documents written for a
+// fixed set of diagnostics against testdata/diag/report.want.json and
+// report.want.sarif.
+func TestGoldenDiagFormats(t *testing.T) {
+	rules := []diag.Rule{
+		{ID: "case/malformed", Description: "A case tag with a bad or out-of-range count is ignored and removed."},
+		{ID: "drop-tags/unknown", Description: "A tag no transform understands is removed from the output."},
+	}
+	ds := []diag.Diagnostic{
+		{File: "docs/a b.txt", Line: 3, Col: 7, Rule: "drop-tags/unknown", Message: "unknown tag (cpa) is removed; did you mean (cap)?"},
+		{File: "/abs/é.txt", Line: 1, Col: 12, Rule: "case/malformed", Message: `(up, x) is not a valid case tag; "x" is not a count`},
+		{File: "<stdin>", Line: 2, Col: 1, Rule: "custom/rule", Message: "a rule missing from the list"},
+	}
+
+	cases := []struct {
+		name  string
+		write func(*strings.Builder) error
+	}{
+		{"report.want.json", func(w *strings.Builder) error { return diag.WriteJSON(w, ds) }},
+		{"report.want.sarif", func(w *strings.Builder) error {
+			return diag.WriteSARIF(w, diag.Tool{Name: "goreloaded", Version: "1.0.0"}, rules, ds)
+		}},
+		{"empty.want.json", func(w *strings.Builder) error { return diag.WriteJSON(w, nil) }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("../testdata/diag", tc.name))
+			if err != nil {
+				t.Fatalf("Failed to read want file: %v", err)
+			}
+			var got strings.Builder
+			if err := tc.write(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != string(want) {
+				t.Errorf("Test %s failed:\nGot:\n%s\nWant:\n%s", tc.name, got.String(), want)
+			}
+		})
+	}
+}
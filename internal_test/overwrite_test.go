@@ -0,0 +1,105 @@
+package internal_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/io"
+)
+
+func TestCheckOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	exists, missing := filepath.Join(dir, "exists.txt"), filepath.Join(dir, "missing.txt")
+	if err := os.WriteFile(exists, []byte("text\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// no terminal on stdin, even though /dev/null is a character device
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+	stdin := os.Stdin
+	os.Stdin = null
+	defer func() { os.Stdin = stdin }()
+	if io.IsTerminal(null) {
+		t.Errorf("IsTerminal(%s) = true", os.DevNull)
+	}
+
+	cases := []struct {
+		name      string
+		file      string
+		policy    io.OverwritePolicy
+		cancelled bool
+	}{
+		{"prompt, new file", missing, io.OverwritePrompt, false},
+		{"prompt, no terminal", exists, io.OverwritePrompt, true},
+		{"force", exists, io.OverwriteForce, false},
+		{"never", exists, io.OverwriteNever, true},
+		{"never, new file", missing, io.OverwriteNever, false},
+		{"stdout", io.Stdio, io.OverwriteNever, false},
+	}
+	for _, tc := range cases {
+		err := io.CheckOverwrite(tc.file, tc.policy)
+		if tc.cancelled && !errors.Is(err, io.ErrCancelled) || !tc.cancelled && err != nil {
+			t.Errorf("%s: CheckOverwrite = %v, cancelled %v", tc.name, err, tc.cancelled)
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "goreloaded")
+	if out, err := exec.Command("go", "build", "-o", bin, "..").CombinedOutput(); err != nil {
+		t.Skipf("cannot build the command: %v\n%s", err, out)
+	}
+	in, out := filepath.Join(dir, "in.txt"), filepath.Join(dir, "out.txt")
+	outDir := filepath.Join(dir, "out")
+	for path, text := range map[string]string{in: "it (up) works\n", out: "old\n", filepath.Join(outDir, "in.txt"): "old\n"} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name string
+		args []string
+		code int
+		want string // content of out.txt afterwards
+	}{
+		{"no terminal to ask", []string{in, out}, 1, "old\n"},
+		{"no-clobber", []string{"--no-clobber", in, out}, 1, "old\n"},
+		{"batch, no terminal to ask", []string{"-o", outDir, in}, 1, "old\n"},
+		{"batch, no-clobber", []string{"--no-clobber", "-o", outDir, in}, 1, "old\n"},
+		{"missing input", []string{"--force", filepath.Join(dir, "nope.txt"), out}, 2, "old\n"},
+		{"batch, missing input", []string{"--force", "-o", outDir, filepath.Join(dir, "nope.txt")}, 2, "old\n"},
+		{"conflicting flags", []string{"--force", "--no-clobber", in, out}, 2, "old\n"},
+		{"force", []string{"--force", in, out}, 0, "IT works\n"},
+	}
+	for _, tc := range cases {
+		cmd := exec.Command(bin, tc.args...)
+		stdin, err := os.Open(os.DevNull)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd.Stdin = stdin
+		stderr, _ := cmd.CombinedOutput()
+		stdin.Close()
+		if code := cmd.ProcessState.ExitCode(); code != tc.code {
+			t.Errorf("%s: exit code %d, want %d\n%s", tc.name, code, tc.code, stderr)
+		}
+		if strings.Contains(string(stderr), "Overwrite") {
+			t.Errorf("%s: prompted without a terminal:\n%s", tc.name, stderr)
+		}
+		if data, _ := os.ReadFile(out); string(data) != tc.want {
+			t.Errorf("%s: %s holds %q, want %q", tc.name, out, data, tc.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package internal_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/pipeline"
+	"go-reloaded/reloaded"
+)
+
+func TestReloadedProcess(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		opts reloaded.Options
+		want string
+	}{
+		{"doc example", "it (cap) was a apple", reloaded.Options{}, "It was an apple"},
+		{"skip hex", "1E (hex) and 10 (bin) , i said", reloaded.Options{SkipHex: true}, "1E and 2, I said"},
+		{"skip bin and punctuation", "1E (hex) and 10 (bin) , i said", reloaded.Options{SkipBin: true, SkipPunctuation: true}, "30 and 10 , I said"},
+		{"skip case and articles", "it (up) was a apple", reloaded.Options{SkipCase: true, SkipArticles: true}, "it was a apple"},
+		{"skip quotes and pronoun", "he said ' hi ' , i think", reloaded.Options{SkipQuotes: true, SkipPronoun: true}, "he said ' hi ', i think"},
+		{"disable a rule", "1E (hex) it (up)", reloaded.Options{Disable: []string{"hex"}}, "1E IT"},
+		{"custom stages", "1E (hex) it (up)", reloaded.Options{Stages: []string{"hex"}}, "30  it (up)"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := reloaded.Process(tc.in, tc.opts)
+			if err != nil {
+				t.Fatalf("Process(%q): %v", tc.in, err)
+			}
+			if got != tc.want {
+				t.Errorf("Process(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReloadedErrors(t *testing.T) {
+	if _, err := reloaded.Process("x", reloaded.Options{Stages: []string{"nope"}}); err == nil {
+		t.Error("unknown stage: no error")
+	}
+	if _, err := reloaded.Process("x", reloaded.Options{Disable: []string{"nope"}}); err == nil {
+		t.Error("unknown rule: no error")
+	}
+	if _, err := reloaded.Process("bad \xff byte", reloaded.Options{}); !errors.Is(err, reloaded.ErrInvalidUTF8) {
+		t.Errorf("invalid UTF-8: err = %v, want ErrInvalidUTF8", err)
+	}
+}
+
+// The zero Options must give exactly what the goreloaded command prints.
+func TestReloadedMatchesPipeline(t *testing.T) {
+	p, err := reloaded.NewProcessor(reloaded.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob("../testdata/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range files {
+		if strings.HasSuffix(path, ".want.txt") {
+			continue
+		}
+		input, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := p.Process(string(input))
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if want := pipeline.ProcessText(string(input)); got != want {
+			t.Errorf("%s: Processor.Process differs from pipeline.ProcessText", path)
+		}
+	}
+}
+
+func TestRegisterStage(t *testing.T) {
+	var ran []string
+	record := func(name string) reloaded.Stage {
+		return reloaded.StageFunc(name, func(toks []reloaded.Token) []reloaded.Token {
+			ran = append(ran, name)
+			return toks
+		})
+	}
+	shout := reloaded.StageFunc("test-shout", func(toks []reloaded.Token) []reloaded.Token {
+		for i, t := range toks {
+			if t.K == reloaded.Word {
+				toks[i].Text = strings.ToUpper(t.Text)
+			}
+		}
+		return toks
+	})
+	for _, s := range []reloaded.Stage{record("test-first"), record("test-second"), shout} {
+		if err := reloaded.RegisterStage(s); err != nil {
+			t.Fatalf("RegisterStage(%q): %v", s.Name(), err)
+		}
+	}
+
+	for _, name := range []string{"test-first", "hex", ""} {
+		if err := reloaded.RegisterStage(record(name)); err == nil {
+			t.Errorf("RegisterStage(%q): no error for a taken or empty name", name)
+		}
+	}
+
+	cases := []struct {
+		name string
+		opts reloaded.Options
+		in   string
+		want string
+		ran  []string
+	}{
+		{"in order", reloaded.Options{Stages: []string{"test-second", "hex", "spaces", "test-first", "test-second"}},
+			"ff (hex) it", "255 it", []string{"test-second", "test-first", "test-second"}},
+		{"disabled by name", reloaded.Options{Stages: []string{"test-first", "test-second"}, Disable: []string{"test-first"}},
+			"it", "it", []string{"test-second"}},
+		{"after the built-in stages", reloaded.Options{Stages: append(reloaded.DefaultStages(), "test-shout")},
+			"it (cap) was a apple", "IT WAS AN APPLE", nil},
+		{"not in the order", reloaded.Options{}, "it (cap) was", "It was", nil},
+	}
+	for _, tc := range cases {
+		ran = nil
+		got, err := reloaded.Process(tc.in, tc.opts)
+		if err != nil {
+			t.Fatalf("%s: Process: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: Process(%q) = %q, want %q", tc.name, tc.in, got, tc.want)
+		}
+		if !reflect.DeepEqual(ran, tc.ran) {
+			t.Errorf("%s: stages ran %v, want %v", tc.name, ran, tc.ran)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package internal_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"go-reloaded/internal/token"
+)
+
+func TestTokenizeRoundTrip(t *testing.T) {
+	cases := []string{
+		"",
+		"plain words, and punctuation... done!?",
+		"Tom & Jerry earn 50% of $5 — #1 * 2 = 4 @home [see] {x} <b> ~ ^ | \\ ` …",
+		"emoji 👨‍👩‍👧 and flags 🇫🇷, combining é",
+		"unmatched ) and ( and (closed) and (up, 2) tags",
+		"'quotes' \"double\" it's rock-'n'-roll",
+		"-1A +5 #ff 0x1F 1010_0101 - alone",
+		"1,000 3.14 10:30 v1.2.3 192.168.0.1:8080 16:9",
+		"https://example.com/a/b.html me@example.com ./internal/io/file.go",
+		"tabs\tand\r\nnewlines\n\n  indented",
+		"invalid \xff\xfe utf-8 \xc3",
+		"placeholder \U000F0000 and \U00100000 runes",
+	}
+	for _, s := range cases {
+		if got := token.Join(token.Tokenize(s)); got != s {
+			t.Errorf("Join(Tokenize(%q)) = %q", s, got)
+		}
+	}
+
+	// random mixes of the characters the tokenizer treats specially
+	alphabet := []rune("ab1 \n\t.,!?:;—/'\"()-+#_&%$*[]{}<>@~…😀é́�")
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		var sb strings.Builder
+		for j := rng.Intn(40); j > 0; j-- {
+			sb.WriteRune(alphabet[rng.Intn(len(alphabet))])
+		}
+		s := sb.String()
+		if got := token.Join(token.Tokenize(s)); got != s {
+			t.Fatalf("Join(Tokenize(%q)) = %q", s, got)
+		}
+	}
+}
+
+func TestTokenizeKeepsPositions(t *testing.T) {
+	s := "a & b\n(c) ) é😀 d"
+	for _, tok := range token.Tokenize(s) {
+		if !strings.HasPrefix(s[tok.Off:], tok.Text) {
+			t.Errorf("%s %q at offset %d does not match the input", tok.K, tok.Text, tok.Off)
+		}
+	}
+}
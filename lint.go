@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"go-reloaded/internal/diag"
+	"go-reloaded/internal/io"
+	"go-reloaded/internal/transform"
+)
+
+// runLint implements `goreloaded lint [flags] [<file|dir|glob>...]`: it reports
+// malformed, unknown, dangling and no-op tags, unconvertible numbers and
+// unmatched quotes without modifying anything, skipping the rules a config
+// disables. No args means stdin.
+// Returns exitDirty if anything was reported.
+func runLint(argv []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	ext := fs.String("ext", ".txt", "only pick files with this extension when walking directories")
+	configPath := fs.String("config", "", "use this config `file` instead of discovering .goreloaded.toml/.json")
+	format := fs.String("format", "text", "output `format`: text, json or sarif (SARIF 2.1.0)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: goreloaded lint [flags] [<file|dir|glob>...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(argv); err != nil {
+		return exitError
+	}
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		fmt.Fprintf(os.Stderr, "Error: unknown --format %q (want text, json or sarif)\n", *format)
+		return exitError
+	}
+	pipelines := newPipelineCache(*configPath)
+
+	inputs := []io.Input{{Path: io.Stdio, Rel: "<stdin>"}}
+	if fs.NArg() > 0 {
+		var err error
+		if inputs, err = io.ExpandInputs(fs.Args(), *ext); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	}
+
+	code := exitOK
+	var all []diag.Diagnostic
+	for _, in := range inputs {
+		name := in.Path
+		if name == io.Stdio {
+			name = in.Rel
+		}
+		text, err := io.ReadFile(in.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			code = exitError
+			continue
+		}
+		p, err := pipelines.forInput(in.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			code = exitError
+			continue
+		}
+
+		ds := p.Lint(text)
+		for i := range ds {
+			ds[i].File = name
+		}
+		all = append(all, ds...)
+	}
+
+	var err error
+	switch *format {
+	case "json":
+		err = diag.WriteJSON(os.Stdout, all)
+	case "sarif":
+		err = diag.WriteSARIF(os.Stdout, diag.Tool{Name: "goreloaded", Version: version}, transform.LintRules, all)
+	default:
+		err = diag.WriteText(os.Stdout, all)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if code == exitOK && len(all) > 0 {
+		code = exitDirty
+	}
+	return code
+}
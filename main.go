@@ -1,37 +1,173 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"runtime"
 
+	"go-reloaded/internal/html"
 	"go-reloaded/internal/io"
-	"go-reloaded/internal/pipeline"
+	"go-reloaded/internal/markdown"
 )
 
+const version = "1.0.0"
+
+// Exit codes, so automation can tell outcomes apart.
+const (
+	exitOK        = 0 // output written
+	exitCancelled = 1 // nothing written on purpose (declined, --no-clobber, no terminal)
+	exitDirty     = 1 // --check/--diff: some input is not normalized yet; lint: problems found
+	exitError     = 2 // bad usage or I/O error
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: goreloaded [flags] [<input> <output>]  (use - for stdin/stdout)")
+	fmt.Fprintln(os.Stderr, "       goreloaded [flags] -o <outdir> <file|dir|glob>...")
+	fmt.Fprintln(os.Stderr, "       goreloaded [flags] --in-place[=SUFFIX] <file|dir|glob>...")
+	fmt.Fprintln(os.Stderr, "       goreloaded --check|--diff [<file|dir|glob>...]")
+	fmt.Fprintln(os.Stderr, "       goreloaded --explain[=json] [<file|dir|glob>...]")
+	fmt.Fprintln(os.Stderr, "       goreloaded lint [flags] [<file|dir|glob>...]")
+	fmt.Fprintf(os.Stderr, "Exit codes: %d success, %d cancelled (or --check found changes), %d usage or I/O error\n", exitOK, exitCancelled, exitError)
+	flag.PrintDefaults()
+}
+
+// flagSet reports whether the named flag was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 func main() {
-	if len(os.Args) != 3 {
-		fmt.Println("Usage: goreloaded <input> <output>")
-		os.Exit(1)
+	os.Exit(run())
+}
+
+func run() int {
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		return runLint(os.Args[2:])
+	}
+
+	outDir := flag.String("o", "", "batch mode: write results into `dir`, mirroring the input tree")
+	ext := flag.String("ext", ".txt", "batch mode: only pick files with this extension when walking directories")
+	jobs := flag.Int("j", runtime.NumCPU(), "batch mode: number of files processed concurrently")
+	var inPlace optionalFlag
+	flag.Var(&inPlace, "in-place", "edit files in place (atomic replace); with =`SUFFIX` keep a backup")
+	force := flag.Bool("force", false, "overwrite existing output files without asking")
+	noClobber := flag.Bool("no-clobber", false, "never overwrite existing output files")
+	check := flag.Bool("check", false, "write nothing; list inputs that would change and exit 1 if any")
+	showDiff := flag.Bool("diff", false, "write nothing; print a unified diff for inputs that would change")
+	configPath := flag.String("config", "", "use this config `file` instead of discovering .goreloaded.toml/.json")
+	var explainFmt optionalFlag
+	flag.Var(&explainFmt, "explain", "write nothing; report what each stage changed (=json for JSON)")
+	markdownMode := flag.Bool("markdown", false, "only process Markdown prose; leave code, links and markup intact (default -ext .md)")
+	htmlMode := flag.Bool("html", false, "only process HTML/XML text nodes; leave tags, entities and <pre>/<code>/<script> intact (default -ext .html)")
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+	pipelines := newPipelineCache(*configPath)
+
+	if *markdownMode || *htmlMode {
+		if *markdownMode && *htmlMode {
+			fmt.Fprintln(os.Stderr, "Error: --markdown and --html are mutually exclusive")
+			return exitError
+		}
+		if explainFmt.enabled {
+			fmt.Fprintln(os.Stderr, "Error: --explain cannot be combined with --markdown or --html")
+			return exitError
+		}
+		pipelines.document = markdown.Process
+		defaultExt := ".md"
+		if *htmlMode {
+			pipelines.document, defaultExt = html.Process, ".html"
+		}
+		if !flagSet("ext") {
+			*ext = defaultExt
+		}
+	}
+
+	if (*check || *showDiff || explainFmt.enabled) && (*outDir != "" || inPlace.enabled) {
+		fmt.Fprintln(os.Stderr, "Error: --check/--diff/--explain cannot be combined with -o or --in-place")
+		return exitError
+	}
+	if explainFmt.enabled {
+		return runExplain(pipelines, args, *ext, explainFmt.value)
+	}
+	if *check || *showDiff {
+		return runCheck(pipelines, args, *ext, *check, *showDiff)
+	}
+
+	if *force && *noClobber {
+		fmt.Fprintln(os.Stderr, "Error: --force and --no-clobber are mutually exclusive")
+		return exitError
+	}
+	policy := io.OverwritePrompt
+	switch {
+	case *force:
+		policy = io.OverwriteForce
+	case *noClobber:
+		policy = io.OverwriteNever
+	}
+
+	if *outDir != "" || inPlace.enabled {
+		if len(args) == 0 || (*outDir != "" && inPlace.enabled) {
+			usage()
+			return exitError
+		}
+		opts := batchOptions{
+			outDir:       *outDir,
+			ext:          *ext,
+			jobs:         *jobs,
+			overwrite:    policy,
+			inPlace:      inPlace.enabled,
+			backupSuffix: inPlace.value,
+			pipelines:    pipelines,
+		}
+		return runBatch(args, opts)
 	}
 
-	inputFile := os.Args[1]
-	outputFile := os.Args[2]
+	// No args: stdin -> stdout. "-" stands for stdin/stdout in either position.
+	if len(args) != 0 && len(args) != 2 {
+		usage()
+		return exitError
+	}
+
+	inputFile, outputFile := io.Stdio, io.Stdio
+	if len(args) == 2 {
+		inputFile = args[0]
+		outputFile = args[1]
+	}
 
+	if err := io.CheckOverwrite(outputFile, policy); err != nil {
+		if errors.Is(err, io.ErrCancelled) {
+			fmt.Fprintln(os.Stderr, err)
+			return exitCancelled
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	// read only after the prompt, which may need stdin
 	text, err := io.ReadFile(inputFile)
 	if err != nil {
-		fmt.Printf("Error reading file: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		return exitError
 	}
 
-	if err := io.CheckOverwrite(outputFile); err != nil {
-		fmt.Printf("Cancelled: %v\n", err)
-		os.Exit(1)
+	result, err := pipelines.process(inputFile, text)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
 	}
 
-	result := pipeline.ProcessText(text)
-
 	if err := io.WriteFile(outputFile, result); err != nil {
-		fmt.Printf("Error writing file: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+		return exitError
 	}
+	return exitOK
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"go-reloaded/internal/config"
+	"go-reloaded/internal/io"
+	"go-reloaded/internal/pipeline"
+)
+
+// pipelineCache hands out the config and pipeline for each input file:
+// the --config file if given, otherwise the nearest .goreloaded.toml/.json
+// found by walking up from the input's directory. Safe for concurrent use.
+type pipelineCache struct {
+	configPath string // --config; "" means discover per input
+
+	// document, if set, runs the pipeline over the prose of a structured
+	// document (--markdown, --html) instead of over the whole text.
+	document func(text string, process func(string) string) (string, error)
+
+	mu        sync.Mutex
+	byDir     map[string]string             // input dir -> config path that applies
+	configs   map[string]*config.Config     // config path -> loaded config
+	pipelines map[string]*pipeline.Pipeline // config path ("" = built-in) -> pipeline
+}
+
+func newPipelineCache(configPath string) *pipelineCache {
+	return &pipelineCache{
+		configPath: configPath,
+		byDir:      map[string]string{},
+		configs:    map[string]*config.Config{},
+		pipelines:  map[string]*pipeline.Pipeline{},
+	}
+}
+
+// configFor returns the config that applies to input (io.Stdio means the
+// working directory), or nil if there is none. Callers must hold c.mu.
+func (c *pipelineCache) configFor(input string) (*config.Config, error) {
+	dir := "."
+	if input != io.Stdio {
+		dir = filepath.Dir(input)
+	}
+	path, ok := c.byDir[dir]
+	if !ok {
+		var err error
+		if path, err = config.ForDir(c.configPath, dir); err != nil {
+			return nil, err
+		}
+		c.byDir[dir] = path
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	if cfg, ok := c.configs[path]; ok {
+		return cfg, nil
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	c.configs[path] = cfg
+	return cfg, nil
+}
+
+// forInput returns the pipeline to use for input.
+func (c *pipelineCache) forInput(input string) (*pipeline.Pipeline, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cfg, err := c.configFor(input)
+	if err != nil {
+		return nil, err
+	}
+	path := ""
+	opts := pipeline.Options{}
+	if cfg != nil {
+		path, opts = cfg.Path, cfg.Options()
+	}
+
+	if p, ok := c.pipelines[path]; ok {
+		return p, nil
+	}
+	p, err := pipeline.New(opts)
+	if err != nil {
+		if path != "" {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return nil, err
+	}
+	c.pipelines[path] = p
+	return p, nil
+}
+
+// process runs the configured pipeline for input over text.
+func (c *pipelineCache) process(input, text string) (string, error) {
+	p, err := c.forInput(input)
+	if err != nil {
+		return "", err
+	}
+	if c.document != nil {
+		return c.document(text, p.Process)
+	}
+	return p.Process(text), nil
+}
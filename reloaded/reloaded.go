@@ -0,0 +1,122 @@
+// Package reloaded is the public, importable API of go-reloaded.
+//
+// It wraps the internal pipeline so other Go programs can run the same
+// formatter as the goreloaded command:
+//
+//	out, err := reloaded.Process("it (cap) was a apple", reloaded.Options{})
+//	// out == "It was an apple"
+//
+// For repeated use, build a Processor once and call its Process method;
+// a Processor is safe for concurrent use.
+//
+// Custom passes implement Stage and are added with RegisterStage, then
+// named in Options.Stages.
+package reloaded
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	"go-reloaded/internal/pipeline"
+	"go-reloaded/internal/token"
+)
+
+// ErrInvalidUTF8 is returned for input that is not valid UTF-8.
+// The tokenizer works on runes and would otherwise replace bad bytes silently.
+var ErrInvalidUTF8 = errors.New("reloaded: input is not valid UTF-8")
+
+// Token is one piece of tokenized text, as seen by a Stage.
+type Token = token.Tok
+
+// Kind classifies a Token.
+type Kind = token.Kind
+
+// Token kinds.
+const (
+	Word  = token.Word
+	Space = token.Space
+	Quote = token.Quote
+	Punct = token.Punct
+	Group = token.Group
+	Tag   = token.Tag
+
+	Protected = token.Protected // URL, email address or file path
+	Symbol    = token.Symbol    // any other character, kept as written
+)
+
+// Stage is one pass over the token stream.
+type Stage = pipeline.Stage
+
+// StageFunc wraps a function as a named Stage.
+func StageFunc(name string, fn func([]Token) []Token) Stage {
+	return pipeline.StageFunc(name, fn)
+}
+
+// RegisterStage makes s available by name in Options.Stages.
+// It fails if the name is already taken.
+func RegisterStage(s Stage) error {
+	return pipeline.Register(s)
+}
+
+// DefaultStages returns the standard stage order.
+func DefaultStages() []string {
+	return append([]string(nil), pipeline.DefaultOrder...)
+}
+
+// Options switches individual rules off. The zero value runs every rule,
+// exactly like the goreloaded command.
+type Options struct {
+	SkipHex         bool // "1E (hex)" -> "30"
+	SkipBin         bool // "10 (bin)" -> "2"
+	SkipCase        bool // (up), (low), (cap) and (up, n) forms
+	SkipArticles    bool // "a apple" -> "an apple"
+	SkipQuotes      bool // "' word '" -> "'word'"
+	SkipPunctuation bool // "word ,next" -> "word, next"
+	SkipPronoun     bool // "i" -> "I"
+
+	// Stages replaces the standard order (see DefaultStages) when not nil.
+	Stages []string
+	// Disable drops stages by stage name or rule name ("hex", "quotes", ...).
+	Disable []string
+}
+
+// Processor runs the pipeline with a fixed set of Options.
+type Processor struct {
+	p *pipeline.Pipeline
+}
+
+// NewProcessor returns a Processor for opts. It fails on unknown stage or rule names.
+func NewProcessor(opts Options) (*Processor, error) {
+	p, err := pipeline.New(pipeline.Options{
+		SkipHex:         opts.SkipHex,
+		SkipBin:         opts.SkipBin,
+		SkipCase:        opts.SkipCase,
+		SkipArticles:    opts.SkipArticles,
+		SkipQuotes:      opts.SkipQuotes,
+		SkipPunctuation: opts.SkipPunctuation,
+		SkipPronoun:     opts.SkipPronoun,
+		Stages:          opts.Stages,
+		Disable:         opts.Disable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Processor{p: p}, nil
+}
+
+// Process formats text.
+func (p *Processor) Process(text string) (string, error) {
+	if !utf8.ValidString(text) {
+		return "", ErrInvalidUTF8
+	}
+	return p.p.Process(text), nil
+}
+
+// Process formats text with opts. It is shorthand for NewProcessor(opts) followed by Process.
+func Process(text string, opts Options) (string, error) {
+	p, err := NewProcessor(opts)
+	if err != nil {
+		return "", err
+	}
+	return p.Process(text)
+}